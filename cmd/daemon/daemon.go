@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/skycoin/hardware-wallet-go/src/bridge"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/usb"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9510", "address to listen on")
+	origins := flag.String("origins", os.Getenv("BRIDGE_ORIGINS"), "comma separated list of the origins allowed to send requests from a browser")
+	flag.Parse()
+
+	w, err := usb.InitWebUSB()
+	if err != nil {
+		fmt.Println("webusb:", err)
+		os.Exit(1)
+	}
+	defer w.Close()
+	h, err := usb.InitHIDAPI()
+	if err != nil {
+		fmt.Println("hidapi:", err)
+		os.Exit(1)
+	}
+
+	var allowedOrigins []string
+	for _, origin := range strings.Split(*origins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			allowedOrigins = append(allowedOrigins, origin)
+		}
+	}
+
+	server := bridge.NewServer(usb.Init(w, h), allowedOrigins)
+	fmt.Printf("skycoin-hw-daemon %s listening on %s\n", bridge.Version, *addr)
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,5 @@
+#!/usr/bin/env bash
+
+set -e -o pipefail
+
+go build -o $GOPATH/bin/skycoin-hw-daemon .
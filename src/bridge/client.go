@@ -0,0 +1,181 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+const busPrefix = "bridge"
+
+var (
+	// ErrNoAnswer is returned when reading from a device before a whole message was written
+	ErrNoAnswer = errors.New("no answer from the device")
+
+	// knownErrors are the errors of the server returned as is by the client
+	knownErrors = []error{
+		ErrSessionActive,
+		ErrSessionNotFound,
+		ErrOriginNotAllowed,
+	}
+)
+
+// Bus is a usb.Bus giving access to the devices shared by a bridge server.
+// The path of a device is its path on the server prefixed by "bridge".
+type Bus struct {
+	url    string
+	client *http.Client
+}
+
+// NewBus returns a bus connected to the bridge server at serverURL, e.g. http://127.0.0.1:9510
+func NewBus(serverURL string) *Bus {
+	return &Bus{
+		url:    strings.TrimSuffix(serverURL, "/"),
+		client: &http.Client{},
+	}
+}
+
+// post sends body to the endpoint and decodes the answer into response
+func (b *Bus) post(endpoint string, body, response interface{}) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	resp, err := b.client.Post(b.url+endpoint, "application/json", &buf)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var failure errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil || failure.Error == "" {
+			return fmt.Errorf("bridge: %s", resp.Status)
+		}
+		for _, known := range knownErrors {
+			if known.Error() == failure.Error {
+				return known
+			}
+		}
+		return fmt.Errorf("bridge: %s", failure.Error)
+	}
+
+	if response == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// Enumerate returns the devices attached to the bridge server
+func (b *Bus) Enumerate() ([]usb.Info, error) {
+	var devices []Device
+	if err := b.post("/enumerate", nil, &devices); err != nil {
+		return nil, err
+	}
+
+	infos := make([]usb.Info, len(devices))
+	for i, device := range devices {
+		infos[i] = usb.Info{
+			Path:      busPrefix + device.Path,
+			VendorID:  device.VendorID,
+			ProductID: device.ProductID,
+			Serial:    device.Serial,
+		}
+	}
+	return infos, nil
+}
+
+// Connect acquires a session on the device at path, the session is released when the device is closed
+func (b *Bus) Connect(path string) (usb.Device, error) {
+	var session Session
+	if err := b.post("/acquire/"+url.PathEscape(strings.TrimPrefix(path, busPrefix)), nil, &session); err != nil {
+		return nil, err
+	}
+
+	return &RemoteDevice{
+		bus:     b,
+		session: session.Session,
+		written: wire.NewDecoder(nil, wire.ReportSize),
+	}, nil
+}
+
+// Has reports whether path is the path of a device attached to a bridge server
+func (b *Bus) Has(path string) bool {
+	return strings.HasPrefix(path, busPrefix)
+}
+
+// RemoteDevice is a device attached to a bridge server.
+// The reports written are gathered until a whole message can be sent to the server,
+// the reports of the answer are then returned by Read.
+type RemoteDevice struct {
+	bus     *Bus
+	session string
+
+	// written decodes the message being written
+	written *wire.Decoder
+	// answer holds the reports of the answer to the last message
+	answer bytes.Buffer
+}
+
+// Write gathers a report of a message, the message is sent once complete
+func (d *RemoteDevice) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	d.answer.Reset()
+	msg, err := d.written.Feed(p)
+	if err != nil {
+		return 0, err
+	}
+	if msg != nil {
+		if err := d.call(*msg); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// call sends msg to the device and keeps the reports of its answer
+func (d *RemoteDevice) call(msg wire.Message) error {
+	var answer Message
+	if err := d.bus.post("/call/"+d.session, Message{
+		Kind: msg.Kind,
+		Data: hex.EncodeToString(msg.Data),
+	}, &answer); err != nil {
+		return err
+	}
+
+	data, err := hex.DecodeString(answer.Data)
+	if err != nil {
+		return err
+	}
+	reply := wire.Message{Kind: answer.Kind, Data: data}
+	_, err = reply.WriteTo(&d.answer)
+	return err
+}
+
+// Read returns the next report of the answer to the last message
+func (d *RemoteDevice) Read(p []byte) (int, error) {
+	if d.answer.Len() == 0 {
+		return 0, ErrNoAnswer
+	}
+	var report [wire.ReportSize]byte
+	d.answer.Read(report[:])
+	return copy(p, report[:]), nil
+}
+
+// Close releases the session
+func (d *RemoteDevice) Close() error {
+	return d.bus.post("/release/"+d.session, nil, nil)
+}
@@ -0,0 +1,60 @@
+package bridge
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/simulator"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/usb"
+)
+
+func TestBus(t *testing.T) {
+	sim := simulator.New()
+	server := httptest.NewServer(NewServer(usb.Init(simulator.NewBus(sim)), nil))
+	defer server.Close()
+
+	bus := usb.Init(NewBus(server.URL + "/"))
+	infos, err := bus.Enumerate()
+	require.NoError(t, err)
+	require.Equal(t, []usb.Info{{Path: "bridgesimulator0", Serial: "SIMULATOR0"}}, infos)
+
+	device := deviceWallet.NewDevice(deviceWallet.DeviceTypeUSB, deviceWallet.WithBus(bus))
+	msg, err := device.SetMnemonic("cloud flower upset remain green metal below cup stem infant art thank")
+	require.NoError(t, err)
+	require.Equal(t, uint16(messages.MessageType_MessageType_Success), msg.Kind)
+
+	client := deviceWallet.NewClient(device)
+	addresses, err := client.AddressGen(2, 0, false)
+	require.NoError(t, err)
+	require.Len(t, addresses, 2)
+
+	// a whole message is answered in several reports
+	signature, err := client.SignMessage(1, string(make([]byte, 200)))
+	require.NoError(t, err)
+	require.NotEmpty(t, signature)
+
+	// the session is kept by the first device
+	require.NoError(t, device.OpenSession())
+	other := deviceWallet.NewDevice(deviceWallet.DeviceTypeUSB, deviceWallet.WithBus(bus), deviceWallet.WithDevicePath("bridgesimulator0"))
+	_, err = other.GetFeatures()
+	require.Equal(t, ErrSessionActive, err)
+	require.NoError(t, device.CloseSession())
+
+	features, err := deviceWallet.NewClient(other).GetFeatures()
+	require.NoError(t, err)
+	require.True(t, features.GetInitialized())
+
+	// the bridge devices are listed once
+	devices, err := deviceWallet.ListDevices(deviceWallet.WithBus(bus))
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	require.Equal(t, deviceWallet.DeviceTypeUSB, devices[0].Type)
+	require.True(t, devices[0].Features.GetInitialized())
+
+	_, err = bus.Connect("bridgesimulator1")
+	require.Error(t, err)
+}
@@ -0,0 +1,320 @@
+/*
+Package bridge implements an HTTP/JSON server sharing the hardware wallets attached to a usb bus
+with the local applications, and a usb.Bus client of this server.
+
+The server answers POST requests on the following endpoints:
+
+	/                     version of the bridge
+	/enumerate            attached devices and their active session
+	/acquire/{path}       opens a session on the device at path
+	/release/{session}    closes the session
+	/call/{session}       sends a message to the device of the session and returns its answer
+
+Sessions are identified by random ids, only one session can be active on a device. Requests sent by browsers are accepted from the allowed origins only.
+
+A process without USB access uses the devices shared by a server through a Bus:
+
+	device := devicewallet.NewDevice(devicewallet.DeviceTypeUSB, devicewallet.WithBus(usb.Init(bridge.NewBus("http://127.0.0.1:9510"))))
+*/
+package bridge
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/util/logging"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+// Version is the version of the bridge api
+const Version = "1.0.0"
+
+// maxRequestSize is the maximum size of a request body, a hex encoded firmware upload fits in it
+const maxRequestSize = 4 << 20
+
+var (
+	log = logging.MustGetLogger("bridge")
+
+	// ErrSessionActive is returned when acquiring a device which already has an active session
+	ErrSessionActive = errors.New("device already has an active session")
+	// ErrSessionNotFound is returned when using a session which is not active
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrOriginNotAllowed is returned when a request is sent from an origin which is not allowed
+	ErrOriginNotAllowed = errors.New("origin not allowed")
+)
+
+// Device is an attached device as returned by the enumerate endpoint
+type Device struct {
+	Path       string  `json:"path"`
+	VendorID   int     `json:"vendor"`
+	ProductID  int     `json:"product"`
+	Serial     string  `json:"serial"`
+	Bootloader bool    `json:"bootloader"`
+	Session    *string `json:"session"`
+}
+
+// Message is a device message as sent to and returned by the call endpoint
+type Message struct {
+	Kind uint16 `json:"kind"`
+	// Data is the hex encoded protobuf payload
+	Data string `json:"data"`
+}
+
+// Session is returned by the acquire endpoint
+type Session struct {
+	Session string `json:"session"`
+}
+
+// Info is returned by the version endpoint
+type Info struct {
+	Version string `json:"version"`
+}
+
+// errorResponse is the body of the failed requests
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// session is a device acquired by a client
+type session struct {
+	// mu serializes the calls on the device
+	mu     sync.Mutex
+	path   string
+	device *deviceWallet.Device
+}
+
+// Server shares the devices attached to a usb bus
+type Server struct {
+	bus     *usb.USB
+	origins map[string]bool
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	// paths maps the acquired device paths to their session, a path is reserved while its device is opened or closed
+	paths map[string]string
+}
+
+// NewServer returns a server sharing the devices attached to bus.
+// The requests with an Origin header, sent by browsers, are only accepted from allowedOrigins.
+func NewServer(bus *usb.USB, allowedOrigins []string) *Server {
+	origins := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		origins[origin] = true
+	}
+
+	return &Server{
+		bus:      bus,
+		origins:  origins,
+		sessions: make(map[string]*session),
+		paths:    make(map[string]string),
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if !s.origins[origin] {
+			writeError(w, http.StatusForbidden, ErrOriginNotAllowed)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Vary", "Origin")
+	}
+
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "POST")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+		return
+	}
+
+	endpoint, arg := r.URL.Path, ""
+	if parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2); len(parts) == 2 {
+		endpoint, arg = "/"+parts[0], parts[1]
+	}
+
+	switch {
+	case endpoint == "/" && arg == "":
+		writeJSON(w, Info{Version: Version})
+	case endpoint == "/enumerate" && arg == "":
+		s.enumerate(w)
+	case endpoint == "/acquire" && arg != "":
+		s.acquire(w, arg)
+	case endpoint == "/release" && arg != "":
+		s.release(w, arg)
+	case endpoint == "/call" && arg != "":
+		s.call(w, r, arg)
+	default:
+		writeError(w, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+	}
+}
+
+func (s *Server) enumerate(w http.ResponseWriter) {
+	infos, err := s.bus.Enumerate()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	devices := make([]Device, len(infos))
+	for i, info := range infos {
+		devices[i] = Device{
+			Path:       info.Path,
+			VendorID:   info.VendorID,
+			ProductID:  info.ProductID,
+			Serial:     info.Serial,
+			Bootloader: info.Bootloader(),
+		}
+		if id, ok := s.paths[info.Path]; ok {
+			devices[i].Session = &id
+		}
+	}
+	writeJSON(w, devices)
+}
+
+func (s *Server) acquire(w http.ResponseWriter, path string) {
+	id, err := newSessionID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.mu.Lock()
+	if _, ok := s.paths[path]; ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, ErrSessionActive)
+		return
+	}
+	s.paths[path] = id
+	s.mu.Unlock()
+
+	// the device is opened without holding s.mu, a slow device does not block the other requests
+	device := deviceWallet.NewDevice(deviceWallet.DeviceTypeUSB, deviceWallet.WithBus(s.bus), deviceWallet.WithDevicePath(path))
+	if err := device.OpenSession(); err != nil {
+		s.mu.Lock()
+		delete(s.paths, path)
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	s.mu.Lock()
+	s.sessions[id] = &session{
+		path:   path,
+		device: device,
+	}
+	s.mu.Unlock()
+	log.Infof("session %s acquired %s", id, path)
+
+	writeJSON(w, Session{Session: id})
+}
+
+// newSessionID returns a random session id, so that a client cannot guess the sessions of the others
+func newSessionID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+func (s *Server) release(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	ss, ok := s.sessions[id]
+	if ok {
+		delete(s.sessions, id)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, ErrSessionNotFound)
+		return
+	}
+
+	// wait for the ongoing call, the path stays reserved until the device is closed
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if err := ss.device.CloseSession(); err != nil {
+		log.Errorf("closing session %s: %v", id, err)
+	}
+	s.mu.Lock()
+	delete(s.paths, ss.path)
+	s.mu.Unlock()
+	log.Infof("session %s released %s", id, ss.path)
+
+	writeJSON(w, struct{}{})
+}
+
+func (s *Server) call(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	ss, ok := s.sessions[id]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, ErrSessionNotFound)
+		return
+	}
+
+	var request Message
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	data, err := hex.DecodeString(request.Data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	// the session may have been released while waiting for the previous call
+	s.mu.Lock()
+	_, ok = s.sessions[id]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, ErrSessionNotFound)
+		return
+	}
+
+	msg, err := ss.device.SendMessage(wire.Message{Kind: request.Kind, Data: data})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, Message{
+		Kind: msg.Kind,
+		Data: hex.EncodeToString(msg.Data),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("writing response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(errorResponse{Error: err.Error()}); err != nil {
+		log.Errorf("writing response: %v", err)
+	}
+}
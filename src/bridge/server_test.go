@@ -0,0 +1,125 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/simulator"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/usb"
+)
+
+func post(t *testing.T, url, origin string, body interface{}, status int, response interface{}) {
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+	request, err := http.NewRequest(http.MethodPost, url, &buf)
+	require.NoError(t, err)
+	if origin != "" {
+		request.Header.Set("Origin", origin)
+	}
+
+	resp, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, status, resp.StatusCode)
+	if response != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(response))
+	}
+}
+
+func TestServer(t *testing.T) {
+	server := httptest.NewServer(NewServer(usb.Init(simulator.NewBus(simulator.New(), simulator.New())), []string{"https://wallet.skycoin.com"}))
+	defer server.Close()
+
+	var info Info
+	post(t, server.URL, "", nil, http.StatusOK, &info)
+	require.Equal(t, Version, info.Version)
+
+	var devices []Device
+	post(t, server.URL+"/enumerate", "", nil, http.StatusOK, &devices)
+	require.Len(t, devices, 2)
+	require.Equal(t, "simulator0", devices[0].Path)
+	require.Nil(t, devices[0].Session)
+
+	var session Session
+	post(t, server.URL+"/acquire/simulator0", "", nil, http.StatusOK, &session)
+	require.Len(t, session.Session, 32)
+
+	// one session per device
+	var failure errorResponse
+	post(t, server.URL+"/acquire/simulator0", "", nil, http.StatusConflict, &failure)
+	require.Equal(t, ErrSessionActive.Error(), failure.Error)
+	post(t, server.URL+"/acquire/simulator1", "", nil, http.StatusOK, &Session{})
+	post(t, server.URL+"/acquire/simulator2", "", nil, http.StatusNotFound, nil)
+
+	post(t, server.URL+"/enumerate", "", nil, http.StatusOK, &devices)
+	require.Equal(t, session.Session, *devices[0].Session)
+	require.NotNil(t, devices[1].Session)
+
+	data, err := proto.Marshal(&messages.Ping{Message: proto.String("bridge")})
+	require.NoError(t, err)
+	var answer Message
+	post(t, server.URL+"/call/"+session.Session, "", Message{
+		Kind: uint16(messages.MessageType_MessageType_Ping),
+		Data: hex.EncodeToString(data),
+	}, http.StatusOK, &answer)
+	require.Equal(t, uint16(messages.MessageType_MessageType_Success), answer.Kind)
+	data, err = hex.DecodeString(answer.Data)
+	require.NoError(t, err)
+	var success messages.Success
+	require.NoError(t, proto.Unmarshal(data, &success))
+	require.Equal(t, "bridge", success.GetMessage())
+
+	post(t, server.URL+"/call/"+session.Session, "", Message{Data: "zz"}, http.StatusBadRequest, nil)
+	post(t, server.URL+"/call/"+session.Session, "", Message{Data: strings.Repeat("00", maxRequestSize)}, http.StatusBadRequest, nil)
+
+	post(t, server.URL+"/release/"+session.Session, "", nil, http.StatusOK, nil)
+	post(t, server.URL+"/release/"+session.Session, "", nil, http.StatusNotFound, &failure)
+	require.Equal(t, ErrSessionNotFound.Error(), failure.Error)
+	post(t, server.URL+"/call/"+session.Session, "", Message{}, http.StatusNotFound, nil)
+
+	post(t, server.URL+"/enumerate", "", nil, http.StatusOK, &devices)
+	require.Nil(t, devices[0].Session)
+	post(t, server.URL+"/acquire/simulator0", "", nil, http.StatusOK, &Session{})
+
+	post(t, server.URL+"/unknown", "", nil, http.StatusNotFound, nil)
+}
+
+func TestServerOrigin(t *testing.T) {
+	server := httptest.NewServer(NewServer(usb.Init(simulator.NewBus(simulator.New())), []string{"https://wallet.skycoin.com"}))
+	defer server.Close()
+
+	var failure errorResponse
+	post(t, server.URL+"/enumerate", "https://evil.example.com", nil, http.StatusForbidden, &failure)
+	require.Equal(t, ErrOriginNotAllowed.Error(), failure.Error)
+	post(t, server.URL+"/acquire/simulator0", "https://evil.example.com", nil, http.StatusForbidden, nil)
+
+	request, err := http.NewRequest(http.MethodOptions, server.URL+"/enumerate", nil)
+	require.NoError(t, err)
+	request.Header.Set("Origin", "https://wallet.skycoin.com")
+	resp, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, "https://wallet.skycoin.com", resp.Header.Get("Access-Control-Allow-Origin"))
+
+	var devices []Device
+	post(t, server.URL+"/enumerate", "https://wallet.skycoin.com", nil, http.StatusOK, &devices)
+	require.Len(t, devices, 1)
+
+	resp, err = http.Get(server.URL + "/enumerate")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -3,9 +3,6 @@ package cli
 import (
 	"fmt"
 
-	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
-	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
-
 	gcli "github.com/urfave/cli"
 
 	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
@@ -39,76 +36,23 @@ func addressGenCmd() gcli.Command {
 			},
 		},
 		OnUsageError: onCommandUsageError(name),
-		Action: func(c *gcli.Context) {
+		Action: func(c *gcli.Context) (err error) {
 			addressN := c.Int("addressN")
 			startIndex := c.Int("startIndex")
 			confirmAddress := c.Bool("confirmAddress")
 
-			device := deviceWallet.NewDevice(deviceWallet.DeviceTypeFromString(c.String("deviceType")))
-			if device == nil {
-				return
-			}
-
-			var pinEnc string
-			var msg wire.Message
-			msg, err := device.AddressGen(addressN, startIndex, confirmAddress)
+			device, finish, err := newDevice(c)
 			if err != nil {
-				log.Error(err)
-				return
-			}
-
-			for msg.Kind != uint16(messages.MessageType_MessageType_ResponseSkycoinAddress) && msg.Kind != uint16(messages.MessageType_MessageType_Failure) {
-				if msg.Kind == uint16(messages.MessageType_MessageType_PinMatrixRequest) {
-					fmt.Printf("PinMatrixRequest response: ")
-					fmt.Scanln(&pinEnc)
-					pinAckResponse, err := device.PinMatrixAck(pinEnc)
-					if err != nil {
-						log.Error(err)
-						return
-					}
-					log.Infof("PinMatrixAck response: %s", pinAckResponse)
-					continue
-				}
-
-				if msg.Kind == uint16(messages.MessageType_MessageType_PassphraseRequest) {
-					var passphrase string
-					fmt.Printf("Input passphrase: ")
-					fmt.Scanln(&passphrase)
-					passphraseAckResponse, err := device.PassphraseAck(passphrase)
-					if err != nil {
-						log.Error(err)
-						return
-					}
-					log.Infof("PinMatrixAck response: %s", passphraseAckResponse)
-					continue
-				}
-
-				if msg.Kind == uint16(messages.MessageType_MessageType_ButtonRequest) {
-					msg, err = device.ButtonAck()
-					if err != nil {
-						log.Error(err)
-						return
-					}
-					continue
-				}
+				return err
 			}
+			defer finishDevice(finish, &err)
 
-			if msg.Kind == uint16(messages.MessageType_MessageType_ResponseSkycoinAddress) {
-				addresses, err := deviceWallet.DecodeResponseSkycoinAddress(msg)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-				fmt.Println(addresses)
-			} else {
-				failMsg, err := deviceWallet.DecodeFailMsg(msg)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-				fmt.Println("Failed with code: ", failMsg)
-				return
+			addresses, err := deviceWallet.NewClient(device).AddressGen(addressN, startIndex, confirmAddress)
+			if err != nil {
+				return err
 			}
+			fmt.Println(addresses)
+			return nil
 		},
 	}
 }
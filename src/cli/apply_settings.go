@@ -3,12 +3,9 @@ package cli
 import (
 	"fmt"
 
-	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
-
 	gcli "github.com/urfave/cli"
 
 	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
-	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
 )
 
 func applySettingsCmd() gcli.Command {
@@ -33,65 +30,28 @@ func applySettingsCmd() gcli.Command {
 			},
 		},
 		OnUsageError: onCommandUsageError(name),
-		Action: func(c *gcli.Context) {
+		Action: func(c *gcli.Context) (err error) {
 			passphrase := c.Bool("usePassphrase")
 			label := c.String("label")
 
-			device := deviceWallet.NewDevice(deviceWallet.DeviceTypeFromString(c.String("deviceType")))
-			if device == nil {
-				return
+			device, finish, err := newDevice(c)
+			if err != nil {
+				return err
 			}
+			defer finishDevice(finish, &err)
 
-			var msg wire.Message
 			msg, err := device.ApplySettings(passphrase, label)
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
-			for msg.Kind != uint16(messages.MessageType_MessageType_Failure) && msg.Kind != uint16(messages.MessageType_MessageType_Success) {
-				if msg.Kind == uint16(messages.MessageType_MessageType_ButtonRequest) {
-					msg, err = device.ButtonAck()
-					if err != nil {
-						log.Error(err)
-						return
-					}
-					continue
-				}
-
-				if msg.Kind == uint16(messages.MessageType_MessageType_PinMatrixRequest) {
-					var pinEnc string
-					fmt.Printf("PinMatrixRequest response: ")
-					fmt.Scanln(&pinEnc)
-					pinAckResponse, err := device.PinMatrixAck(pinEnc)
-					if err != nil {
-						log.Error(err)
-						return
-					}
-					log.Infof("PinMatrixAck response: %s", pinAckResponse)
-					continue
-				}
-			}
-
-			if msg.Kind == uint16(messages.MessageType_MessageType_Failure) {
-				failMsg, err := deviceWallet.DecodeFailMsg(msg)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-				fmt.Println("Failed with code: ", failMsg)
-				return
+			successMsg, err := deviceWallet.DecodeSuccessOrFailMsg(msg)
+			if err != nil {
+				return err
 			}
 
-			if msg.Kind == uint16(messages.MessageType_MessageType_Success) {
-				successMsg, err := deviceWallet.DecodeSuccessMsg(msg)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-				fmt.Println("Success with code: ", successMsg)
-				return
-			}
+			fmt.Println("Success with code: ", successMsg)
+			return nil
 		},
 	}
 }
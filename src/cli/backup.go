@@ -6,7 +6,6 @@ import (
 	gcli "github.com/urfave/cli"
 
 	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
-	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
 )
 
 func backupCmd() gcli.Command {
@@ -23,44 +22,25 @@ func backupCmd() gcli.Command {
 				EnvVar: "DEVICE_TYPE",
 			},
 		},
-		Action: func(c *gcli.Context) {
-			device := deviceWallet.NewDevice(deviceWallet.DeviceTypeFromString(c.String("deviceType")))
-			if device == nil {
-				return
+		Action: func(c *gcli.Context) (err error) {
+			device, finish, err := newDevice(c)
+			if err != nil {
+				return err
 			}
+			defer finishDevice(finish, &err)
 
 			msg, err := device.Backup()
 			if err != nil {
-				log.Error(err)
-				return
-			}
-
-			if msg.Kind == uint16(messages.MessageType_MessageType_PinMatrixRequest) {
-				var pinEnc string
-				fmt.Printf("PinMatrixRequest response: ")
-				fmt.Scanln(&pinEnc)
-				msg, err := device.PinMatrixAck(pinEnc)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-
-				for msg.Kind == uint16(messages.MessageType_MessageType_ButtonRequest) {
-					msg, err = device.ButtonAck()
-					if err != nil {
-						log.Error(err)
-						return
-					}
-				}
+				return err
 			}
 
 			responseMsg, err := deviceWallet.DecodeSuccessOrFailMsg(msg)
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
 			fmt.Println(responseMsg)
+			return nil
 		},
 	}
 }
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	gcli "github.com/urfave/cli"
+
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/transaction"
+)
+
+func bundleCreateCmd() gcli.Command {
+	name := "bundleCreate"
+	return gcli.Command{
+		Name:  name,
+		Usage: "Create the bundle of an unsigned transaction, to be signed on an offline machine.",
+		Description: `The bundle holds the unsigned transaction, the uxouts spent by its inputs and the change outputs.
+    The uxouts are read from a JSON list of {"hash", "address", "coins", "hours", "time", "addressIndex"} objects,
+    coins in droplets, hours and time those of the uxout creation and addressIndex the index of the address in
+    the device wallet. The coin hours are validated at the head time with the burn factor when the bundle is
+    created and signed.`,
+		Flags: []gcli.Flag{
+			gcli.StringFlag{
+				Name:  "unsignedTransaction",
+				Usage: "Hex encoded unsigned transaction, as returned by createRawTransaction",
+			},
+			gcli.StringFlag{
+				Name:  "uxouts",
+				Usage: "JSON file listing the uxouts spent by the inputs of the transaction",
+			},
+			gcli.StringSliceFlag{
+				Name:  "change",
+				Usage: "Change output of the transaction, as OUTPUT:ADDRESS_INDEX the output number, 0 based, and the index of its address in the wallet",
+			},
+			gcli.StringFlag{
+				Name:  "o, output",
+				Usage: "path of the bundle file written",
+			},
+			headTimeFlag,
+			burnFactorFlag,
+		},
+		OnUsageError: onCommandUsageError(name),
+		Action: func(c *gcli.Context) error {
+			output := c.String("output")
+			if output == "" {
+				return errors.New("the bundle file is given with --output")
+			}
+			headTime, err := requireHeadTime(c)
+			if err != nil {
+				return err
+			}
+			txn, err := transaction.DeserializeHex(strings.TrimSpace(c.String("unsignedTransaction")))
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(c.String("uxouts"))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			uxouts, err := transaction.ReadUxouts(f)
+			if err != nil {
+				return err
+			}
+			changes, err := parseChanges(c.StringSlice("change"))
+			if err != nil {
+				return err
+			}
+
+			bundle, err := transaction.NewBundle(txn, uxouts, changes, headTime, uint32(c.Uint("burnFactor")))
+			if err != nil {
+				return err
+			}
+			if err := writeBundle(output, bundle); err != nil {
+				return err
+			}
+			printBundle(bundle)
+			return nil
+		},
+	}
+}
+
+func bundleInspectCmd() gcli.Command {
+	name := "bundleInspect"
+	return gcli.Command{
+		Name:  name,
+		Usage: "Print the transaction of a bundle, its inputs and outputs.",
+		Flags: []gcli.Flag{
+			gcli.StringFlag{
+				Name:  "f, file",
+				Usage: "path of the bundle file",
+			},
+		},
+		OnUsageError: onCommandUsageError(name),
+		Action: func(c *gcli.Context) error {
+			bundle, err := readBundle(c.String("file"))
+			if err != nil {
+				return err
+			}
+			printBundle(bundle)
+			return nil
+		},
+	}
+}
+
+func bundleSignCmd() gcli.Command {
+	name := "bundleSign"
+	return gcli.Command{
+		Name:  name,
+		Usage: "Ask the device to sign the transaction of a bundle, the signatures are written back to the bundle.",
+		Description: `The signatures are verified against the addresses owning the uxouts before the bundle is written.
+    The bundle file is replaced unless --output is given.`,
+		Flags: []gcli.Flag{
+			gcli.StringFlag{
+				Name:  "f, file",
+				Usage: "path of the bundle file",
+			},
+			gcli.StringFlag{
+				Name:  "o, output",
+				Usage: "path of the signed bundle file written, the bundle file by default",
+			},
+			gcli.StringFlag{
+				Name:   "deviceType",
+				Usage:  "Device type to send instructions to, hardware wallet (USB) or emulator.",
+				EnvVar: "DEVICE_TYPE",
+			},
+		},
+		OnUsageError: onCommandUsageError(name),
+		Action: func(c *gcli.Context) (err error) {
+			device, finish, err := newDevice(c)
+			if err != nil {
+				return err
+			}
+			defer finishDevice(finish, &err)
+			bundle, err := readBundle(c.String("file"))
+			if err != nil {
+				return err
+			}
+			printBundle(bundle)
+
+			report, err := bundle.Sign(device)
+			printChangeReport(report)
+			if err != nil {
+				return err
+			}
+			output := c.String("output")
+			if output == "" {
+				output = c.String("file")
+			}
+			if err := writeBundle(output, bundle); err != nil {
+				return err
+			}
+			fmt.Printf("Signed transaction id: %s\n", bundle.Transaction.Hash().Hex())
+			return nil
+		},
+	}
+}
+
+func bundleFinalizeCmd() gcli.Command {
+	name := "bundleFinalize"
+	return gcli.Command{
+		Name:  name,
+		Usage: "Print the signed transaction of a bundle, ready for injectTransaction.",
+		Flags: []gcli.Flag{
+			gcli.StringFlag{
+				Name:  "f, file",
+				Usage: "path of the signed bundle file",
+			},
+		},
+		OnUsageError: onCommandUsageError(name),
+		Action: func(c *gcli.Context) error {
+			bundle, err := readBundle(c.String("file"))
+			if err != nil {
+				return err
+			}
+			signed, err := bundle.Finalize()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Transaction id: %s\n", signed.Hash().Hex())
+			fmt.Println(signed.SerializeHex())
+			return nil
+		},
+	}
+}
+
+func readBundle(path string) (*transaction.Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return transaction.ReadBundle(f)
+}
+
+func writeBundle(path string, bundle *transaction.Bundle) error {
+	var b bytes.Buffer
+	if err := bundle.Write(&b); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b.Bytes(), 0600)
+}
+
+// printBundle prints the transaction of the bundle, the owners and amounts of its inputs and its outputs
+func printBundle(bundle *transaction.Bundle) {
+	txn := bundle.Transaction
+	status := "unsigned"
+	if bundle.Signed() {
+		status = "signed"
+	}
+	fmt.Printf("Bundle version %d, %s transaction %s\n", transaction.BundleVersion, status, txn.Hash().Hex())
+
+	var hoursIn, hoursOut, coins uint64
+	fmt.Println("Inputs:")
+	for i, ux := range bundle.Uxouts {
+		// the bundle was validated, the hours do not overflow
+		hours, _ := transaction.UxoutHours(ux, bundle.HeadTime)
+		fmt.Printf("  %d %s %s (index %d) %s %d hours\n", i, ux.Hash.Hex(), ux.Address, ux.AddressIndex,
+			transaction.FormatCoins(ux.Coins), hours)
+		hoursIn += hours
+		coins += ux.Coins
+	}
+	fmt.Println("Outputs:")
+	for i, out := range txn.Out {
+		change := ""
+		if index, ok := bundle.Change[i]; ok {
+			change = fmt.Sprintf(" change (index %d)", index)
+		}
+		fmt.Printf("  %d %s %s %d hours%s\n", i, out.Address, transaction.FormatCoins(out.Coins), out.Hours, change)
+		hoursOut += out.Hours
+	}
+	fmt.Printf("Coins: %s, hours: %d spent, %d sent, head time %d, burn factor %d\n", transaction.FormatCoins(coins),
+		hoursIn, hoursOut, bundle.HeadTime, bundle.BurnFactor)
+}
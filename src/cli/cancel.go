@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 
 	gcli "github.com/urfave/cli"
@@ -22,25 +23,26 @@ func cancelCmd() gcli.Command {
 				EnvVar: "DEVICE_TYPE",
 			},
 		},
-		Action: func(c *gcli.Context) {
-			device := deviceWallet.NewDevice(deviceWallet.DeviceTypeFromString(c.String("deviceType")))
-			if device == nil {
-				return
+		Action: func(c *gcli.Context) (err error) {
+			device, finish, err := newDevice(c)
+			if err != nil {
+				return err
 			}
+			defer finishDevice(finish, &err)
 
 			msg, err := device.Cancel()
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
+			// the device answers the cancellation with an ActionCancelled failure
 			responseMsg, err := deviceWallet.DecodeSuccessOrFailMsg(msg)
-			if err != nil {
-				log.Error(err)
-				return
+			if err != nil && !errors.Is(err, deviceWallet.ErrActionCancelled) {
+				return err
 			}
 
 			fmt.Println(responseMsg)
+			return nil
 		},
 	}
 }
@@ -34,29 +34,29 @@ func checkMessageSignatureCmd() gcli.Command {
 			},
 		},
 		OnUsageError: onCommandUsageError(name),
-		Action: func(c *gcli.Context) {
+		Action: func(c *gcli.Context) (err error) {
 			message := c.String("message")
 			signature := c.String("signature")
 			address := c.String("address")
 
-			device := deviceWallet.NewDevice(deviceWallet.DeviceTypeFromString(c.String("deviceType")))
-			if device == nil {
-				return
+			device, finish, err := newDevice(c)
+			if err != nil {
+				return err
 			}
+			defer finishDevice(finish, &err)
 
 			msg, err := device.CheckMessageSignature(message, signature, address)
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
 			responseMsg, err := deviceWallet.DecodeSuccessOrFailMsg(msg)
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
 			fmt.Println(responseMsg)
+			return nil
 		},
 	}
 }
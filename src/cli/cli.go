@@ -93,6 +93,11 @@ func NewApp() (*App, error) {
 		recoveryCmd(),
 		cancelCmd(),
 		transactionSignCmd(),
+		bundleCreateCmd(),
+		bundleInspectCmd(),
+		bundleSignCmd(),
+		bundleFinalizeCmd(),
+		listCmd(),
 		sandbox(),
 	}
 
@@ -100,6 +105,36 @@ func NewApp() (*App, error) {
 	app.Version = Version
 	app.Usage = "the skycoin hardware wallet command line interface"
 	app.Commands = commands
+	app.Flags = []gcli.Flag{
+		gcli.StringFlag{
+			Name:   "devicePath",
+			Usage:  "Path of the device to send instructions to when several are attached, see the list command.",
+			EnvVar: "DEVICE_PATH",
+		},
+		gcli.StringFlag{
+			Name:   "bridge",
+			Usage:  "URL of the skycoin-hw-daemon sharing the USB devices, e.g. http://127.0.0.1:9510",
+			EnvVar: "BRIDGE_URL",
+		},
+		gcli.StringFlag{
+			Name:   "emulatorHost",
+			Usage:  "Host the emulators run on, 127.0.0.1 by default.",
+			EnvVar: "EMULATOR_HOST",
+		},
+		gcli.StringFlag{
+			Name:   "emulatorPorts",
+			Usage:  "Comma separated list of the ports the emulators listen on, 21324 by default.",
+			EnvVar: "EMULATOR_PORTS",
+		},
+		gcli.StringFlag{
+			Name:  "record",
+			Usage: "Record the reports exchanged with the device to the given transcript file.",
+		},
+		gcli.StringFlag{
+			Name:  "replay",
+			Usage: "Replay the given transcript file instead of using a device, fails when the exchange diverges or stops before the end of the transcript.",
+		},
+	}
 	app.EnableBashCompletion = true
 	app.OnUsageError = func(context *gcli.Context, err error, _ bool) error {
 		fmt.Fprintf(context.App.Writer, "Error: %v\n\n", err)
@@ -3,11 +3,9 @@ package cli
 import (
 	"fmt"
 
-	"github.com/gogo/protobuf/proto"
 	gcli "github.com/urfave/cli"
 
 	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
-	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
 )
 
 func featuresCmd() gcli.Command {
@@ -24,40 +22,20 @@ func featuresCmd() gcli.Command {
 				EnvVar: "DEVICE_TYPE",
 			},
 		},
-		Action: func(c *gcli.Context) {
-			device := deviceWallet.NewDevice(deviceWallet.DeviceTypeFromString(c.String("deviceType")))
-			if device == nil {
-				return
+		Action: func(c *gcli.Context) (err error) {
+			device, finish, err := newDevice(c)
+			if err != nil {
+				return err
 			}
+			defer finishDevice(finish, &err)
 
-			msg, err := device.GetFeatures()
+			features, err := deviceWallet.NewClient(device).GetFeatures()
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
-			switch msg.Kind {
-			case uint16(messages.MessageType_MessageType_Features):
-				features := &messages.Features{}
-				err = proto.Unmarshal(msg.Data, features)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-
-				fmt.Println(features)
-			// TODO: figure out if this method can even return success or failure msg.
-			case uint16(messages.MessageType_MessageType_Failure), uint16(messages.MessageType_MessageType_Success):
-				msgData, err := deviceWallet.DecodeSuccessOrFailMsg(msg)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-
-				fmt.Println(msgData)
-			default:
-				log.Errorf("received unexpected message type: %s", messages.MessageType(msg.Kind))
-			}
+			fmt.Println(features)
+			return nil
 		},
 	}
 }
@@ -8,39 +8,130 @@ import (
 	gcli "github.com/urfave/cli"
 
 	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/firmware"
 )
 
 func firmwareUpdate() gcli.Command {
 	name := "firmwareUpdate"
 	return gcli.Command{
-		Name:        name,
-		Usage:       "Update device's firmware.",
-		Description: "",
+		Name:  name,
+		Usage: "Update device's firmware.",
+		Description: `The firmware image is parsed and its signatures are verified against the trusted keys before
+    the firmware of the device is erased. Unsigned, untrusted or malformed images are refused unless
+    --skipVerification is given.`,
 		Flags: []gcli.Flag{
 			gcli.StringFlag{
 				Name:  "f, file",
 				Usage: "path to the firmware .bin file",
 			},
+			gcli.StringFlag{
+				Name:  "version",
+				Usage: "version of the firmware, MAJOR.MINOR.PATCH, checked once the device restarts",
+			},
+			gcli.StringSliceFlag{
+				Name:   "trustedKey",
+				Usage:  "hex public key trusted by the bootloader, in the bootloader order, repeat for each key",
+				EnvVar: firmware.TrustedKeysEnv,
+			},
+			gcli.BoolFlag{
+				Name:  "skipVerification",
+				Usage: "upload an unsigned, untrusted or malformed firmware image",
+			},
+			gcli.DurationFlag{
+				Name:  "timeout",
+				Value: deviceWallet.DefaultRestartTimeout,
+				Usage: "time given to the device to restart on its new firmware",
+			},
+			gcli.StringFlag{
+				Name:   "deviceType",
+				Value:  deviceWallet.DeviceTypeUSB.String(),
+				Usage:  "Device type to send instructions to, hardware wallet (USB) or emulator.",
+				EnvVar: "DEVICE_TYPE",
+			},
 		},
 		OnUsageError: onCommandUsageError(name),
-		Action: func(c *gcli.Context) {
-			device := deviceWallet.NewDevice(deviceWallet.DeviceTypeUSB)
-			if device == nil {
-				return
+		Action: func(c *gcli.Context) (err error) {
+			var version firmware.Version
+			if s := c.String("version"); s != "" {
+				if version, err = firmware.ParseVersion(s); err != nil {
+					return err
+				}
+			}
+
+			device, finish, err := newDevice(c)
+			if err != nil {
+				return err
 			}
+			defer finishDevice(finish, &err)
 
 			filePath := c.String("file")
 			fmt.Printf("File : %s\n", filePath)
-			firmware, err := ioutil.ReadFile(filePath)
+			data, err := ioutil.ReadFile(filePath)
 			if err != nil {
-				panic(err)
+				return err
 			}
-			fmt.Printf("Hash: %x\n", sha256.Sum256(firmware[0x100:]))
-			err = device.FirmwareUpload(firmware, sha256.Sum256(firmware[0x100:]))
+
+			verifier, err := firmware.NewVerifier(c.StringSlice("trustedKey"))
 			if err != nil {
-				log.Error(err)
-				return
+				return err
+			}
+			img, err := verifier.Check(data)
+			if img != nil {
+				img.Version = version
+				fmt.Print(img)
 			}
+			if err != nil {
+				if !c.Bool("skipVerification") {
+					return fmt.Errorf("refusing the firmware image: %v", err)
+				}
+				fmt.Printf("WARNING: %v, uploading anyway\n", err)
+			}
+
+			if img == nil {
+				// a malformed image is uploaded as is, its version is not checked once the device restarts
+				if len(data) < firmware.HeaderSize {
+					return firmware.ErrTruncated
+				}
+				hash := sha256.Sum256(data[firmware.HeaderSize:])
+				fmt.Printf("Hash: %x\n", hash)
+				return device.FirmwareUpload(data, hash)
+			}
+
+			features, err := device.UpdateFirmware(img, deviceWallet.UpdateOptions{
+				Progress:       printUpdateProgress(),
+				RestartTimeout: c.Duration("timeout"),
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Firmware %d.%d.%d installed\n", features.GetMajorVersion(), features.GetMinorVersion(), features.GetPatchVersion())
+			return nil
 		},
 	}
 }
+
+// printUpdateProgress returns a progress callback printing the stages of the update and the upload percentage
+func printUpdateProgress() func(deviceWallet.UpdateProgress) {
+	percent := -1
+	return func(progress deviceWallet.UpdateProgress) {
+		switch progress.Stage {
+		case deviceWallet.UpdateStageUpload:
+			if progress.Total == 0 {
+				return
+			}
+			if p := progress.Sent * 100 / progress.Total; p != percent {
+				percent = p
+				fmt.Printf("\rUploading: %3d%%", p)
+				if p == 100 {
+					fmt.Println()
+				}
+			}
+		case deviceWallet.UpdateStageErase:
+			fmt.Println("Erasing the firmware")
+		case deviceWallet.UpdateStageConfirm:
+			fmt.Println("Check the hash on the device and confirm")
+		case deviceWallet.UpdateStageRestart:
+			fmt.Println("Waiting for the device to restart")
+		}
+	}
+}
@@ -31,28 +31,28 @@ func generateMnemonicCmd() gcli.Command {
 			},
 		},
 		OnUsageError: onCommandUsageError(name),
-		Action: func(c *gcli.Context) {
+		Action: func(c *gcli.Context) (err error) {
 			usePassphrase := c.Bool("usePassphrase")
 			wordCount := uint32(c.Uint64("wordCount"))
 
-			device := deviceWallet.NewDevice(deviceWallet.DeviceTypeFromString(c.String("deviceType")))
-			if device == nil {
-				return
+			device, finish, err := newDevice(c)
+			if err != nil {
+				return err
 			}
+			defer finishDevice(finish, &err)
 
 			msg, err := device.GenerateMnemonic(wordCount, usePassphrase)
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
 			responseMsg, err := deviceWallet.DecodeSuccessOrFailMsg(msg)
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
 			fmt.Println(responseMsg)
+			return nil
 		},
 	}
 }
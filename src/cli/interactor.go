@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	gcli "github.com/urfave/cli"
+
+	"github.com/skycoin/hardware-wallet-go/src/bridge"
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/transport"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/usb"
+)
+
+// stdinInteractor answers the device requests reading from the standard input
+type stdinInteractor struct {
+	reader *bufio.Reader
+}
+
+func newStdinInteractor() *stdinInteractor {
+	return &stdinInteractor{
+		reader: bufio.NewReader(os.Stdin),
+	}
+}
+
+func (si *stdinInteractor) readLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+	line, err := si.reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// RequestPin reads the PIN matrix positions
+func (si *stdinInteractor) RequestPin(pinType messages.PinMatrixRequestType) (string, error) {
+	switch pinType {
+	case messages.PinMatrixRequestType_PinMatrixRequestType_NewFirst:
+		return si.readLine("PinMatrixRequest new PIN: ")
+	case messages.PinMatrixRequestType_PinMatrixRequestType_NewSecond:
+		return si.readLine("PinMatrixRequest confirm new PIN: ")
+	default:
+		return si.readLine("PinMatrixRequest response: ")
+	}
+}
+
+// RequestPassphrase reads the passphrase
+func (si *stdinInteractor) RequestPassphrase() (string, error) {
+	return si.readLine("Input passphrase: ")
+}
+
+// RequestWord reads a seed word
+func (si *stdinInteractor) RequestWord() (string, error) {
+	return si.readLine("Word: ")
+}
+
+// OnButton tells the user the device is waiting for a button press
+func (si *stdinInteractor) OnButton(code messages.ButtonRequestType) error {
+	log.Infof("Waiting for button confirmation on the device (%s)", code)
+	return nil
+}
+
+// deviceOptions returns the options locating the devices given by the global flags.
+// The devicePath flag chooses the device when several are attached, the bridge flag reaches
+// the USB devices through a skycoin-hw-daemon, the emulatorHost and emulatorPorts flags locate the emulators.
+func deviceOptions(c *gcli.Context) ([]deviceWallet.Option, error) {
+	var options []deviceWallet.Option
+	if path := c.GlobalString("devicePath"); path != "" {
+		options = append(options, deviceWallet.WithDevicePath(path))
+	}
+	if url := c.GlobalString("bridge"); url != "" {
+		options = append(options, deviceWallet.WithBus(usb.Init(bridge.NewBus(url))))
+	}
+
+	var ports []int
+	if s := c.GlobalString("emulatorPorts"); s != "" {
+		var err error
+		if ports, err = deviceWallet.ParsePorts(s); err != nil {
+			return nil, err
+		}
+	}
+	if host := c.GlobalString("emulatorHost"); host != "" || len(ports) > 0 {
+		options = append(options, deviceWallet.WithEmulator(host, ports...))
+	}
+	return options, nil
+}
+
+// newDevice returns a device of the type given by the deviceType flag answering its requests from the standard input,
+// located by the global flags, see deviceOptions. The record flag records the reports exchanged with the device
+// to a transcript file, the replay flag replays such a transcript instead of using a device.
+// finish has to be called once the device is no longer used, see finishDevice.
+func newDevice(c *gcli.Context) (device *deviceWallet.Device, finish func() error, err error) {
+	options, err := deviceOptions(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deviceType := c.String("deviceType")
+	device = deviceWallet.NewDevice(deviceWallet.DeviceTypeFromString(deviceType), options...)
+	if device == nil {
+		return nil, nil, fmt.Errorf("invalid device type %q, valid options are %s or %s",
+			deviceType, deviceWallet.DeviceTypeUSB, deviceWallet.DeviceTypeEmulator)
+	}
+	device.SetInteractor(newStdinInteractor())
+
+	var replay *transport.ReplayDriver
+	if path := c.GlobalString("replay"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer f.Close()
+		transcript, err := transport.ReadTranscript(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		replay = transport.NewReplayDriver(transcript)
+		device.Driver = replay
+	}
+	var record *os.File
+	if path := c.GlobalString("record"); path != "" {
+		if record, err = os.Create(path); err != nil {
+			return nil, nil, err
+		}
+		if device.Driver, err = transport.NewRecordDriver(device.Driver, record); err != nil {
+			record.Close()
+			return nil, nil, err
+		}
+	}
+
+	// finish closes the recorded transcript and reports a replayed transcript diverging or not replayed entirely
+	finish = func() error {
+		if record != nil {
+			if err := record.Close(); err != nil {
+				return err
+			}
+		}
+		if replay != nil {
+			return replay.Done()
+		}
+		return nil
+	}
+	return device, finish, nil
+}
+
+// finishDevice calls the finish hook returned by newDevice along with the device,
+// its error is stored in err unless err already holds the error of the command.
+func finishDevice(finish func() error, err *error) {
+	if finishErr := finish(); *err == nil {
+		*err = finishErr
+	}
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+
+	gcli "github.com/urfave/cli"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+)
+
+func listCmd() gcli.Command {
+	name := "list"
+	return gcli.Command{
+		Name:         name,
+		Usage:        "List the attached devices and emulators.",
+		Description:  "Print the path, type, USB identity, label and device id of every attached hardware wallet and emulator. The path is the one expected by the devicePath flag.",
+		OnUsageError: onCommandUsageError(name),
+		Action: func(c *gcli.Context) error {
+			options, err := deviceOptions(c)
+			if err != nil {
+				return err
+			}
+
+			devices, err := deviceWallet.ListDevices(options...)
+			if err != nil {
+				return err
+			}
+
+			if len(devices) == 0 {
+				fmt.Println("No device attached")
+				return nil
+			}
+			for _, device := range devices {
+				fmt.Printf("Path: %s\n", device.Path)
+				fmt.Printf("  Type: %s\n", device.Type)
+				fmt.Printf("  VendorID: 0x%04x ProductID: 0x%04x\n", device.VendorID, device.ProductID)
+				fmt.Printf("  Serial: %s\n", device.Serial)
+				if device.Features == nil {
+					fmt.Println("  Features: unavailable")
+					continue
+				}
+				fmt.Printf("  Label: %s\n", device.Features.GetLabel())
+				fmt.Printf("  DeviceID: %s\n", device.Features.GetDeviceId())
+				fmt.Printf("  Firmware: %d.%d.%d\n", device.Features.GetMajorVersion(), device.Features.GetMinorVersion(), device.Features.GetPatchVersion())
+			}
+			return nil
+		},
+	}
+}
@@ -6,7 +6,6 @@ import (
 	gcli "github.com/urfave/cli"
 
 	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
-	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
 )
 
 func recoveryCmd() gcli.Command {
@@ -36,48 +35,28 @@ func recoveryCmd() gcli.Command {
 			},
 		},
 		OnUsageError: onCommandUsageError(name),
-		Action: func(c *gcli.Context) {
-			device := deviceWallet.NewDevice(deviceWallet.DeviceTypeFromString(c.String("deviceType")))
-			if device == nil {
-				return
+		Action: func(c *gcli.Context) (err error) {
+			device, finish, err := newDevice(c)
+			if err != nil {
+				return err
 			}
+			defer finishDevice(finish, &err)
 
 			passphrase := c.Bool("usePassphrase")
 			dryRun := c.Bool("dryRun")
 			wordCount := uint32(c.Uint64("wordCount"))
 			msg, err := device.Recovery(wordCount, passphrase, dryRun)
 			if err != nil {
-				log.Error(err)
-				return
-			}
-
-			for msg.Kind == uint16(messages.MessageType_MessageType_WordRequest) {
-				var word string
-				fmt.Printf("Word: ")
-				fmt.Scanln(&word)
-				msg, err = device.WordAck(word)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-			}
-
-			if msg.Kind == uint16(messages.MessageType_MessageType_ButtonRequest) {
-				// Send ButtonAck
-				msg, err = device.ButtonAck()
-				if err != nil {
-					log.Error(err)
-					return
-				}
+				return err
 			}
 
 			responseMsg, err := deviceWallet.DecodeSuccessOrFailMsg(msg)
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
 			fmt.Println(responseMsg)
+			return nil
 		},
 	}
 }
@@ -1,14 +1,9 @@
 package cli
 
 import (
-	"fmt"
-
-	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
-
 	gcli "github.com/urfave/cli"
 
 	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
-	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
 )
 
 func sandbox() gcli.Command {
@@ -19,93 +14,47 @@ func sandbox() gcli.Command {
 		Description:  "",
 		Flags:        []gcli.Flag{},
 		OnUsageError: onCommandUsageError(name),
-		Action: func(c *gcli.Context) {
-			device := deviceWallet.NewDevice(deviceWallet.DeviceTypeFromString(c.String("deviceType")))
-			if device == nil {
-				return
+		Action: func(c *gcli.Context) (err error) {
+			device, finish, err := newDevice(c)
+			if err != nil {
+				return err
 			}
+			defer finishDevice(finish, &err)
 
-			_, err := device.Wipe()
+			_, err = device.Wipe()
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
 			_, err = device.SetMnemonic("cloud flower upset remain green metal below cup stem infant art thank")
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
-			var pinEnc string
-			var msg wire.Message
-			msg, err = device.ChangePin()
+			_, err = device.ChangePin()
 			if err != nil {
-				log.Error(err)
-				return
-			}
-
-			for msg.Kind == uint16(messages.MessageType_MessageType_PinMatrixRequest) {
-				log.Printf("PinMatrixRequest response: ")
-				fmt.Scanln(&pinEnc)
-				msg, err = device.PinMatrixAck(pinEnc)
-				if err != nil {
-					log.Error(err)
-					return
-				}
+				return err
 			}
 
 			// come on one-more time
 			// testing what happen when we try to change an existing pin code
-			msg, err = device.ChangePin()
+			_, err = device.ChangePin()
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
-			for msg.Kind == uint16(messages.MessageType_MessageType_PinMatrixRequest) {
-				log.Printf("PinMatrixRequest response: ")
-				fmt.Scanln(&pinEnc)
-				msg, err = device.PinMatrixAck(pinEnc)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-			}
-
-			msg, err = device.AddressGen(9, 15, false)
+			msg, err := device.AddressGen(9, 15, false)
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 
-			if msg.Kind == uint16(messages.MessageType_MessageType_PinMatrixRequest) {
-				log.Printf("PinMatrixRequest response: ")
-				fmt.Scanln(&pinEnc)
-				msg, err = device.PinMatrixAck(pinEnc)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-
-				if msg.Kind == uint16(messages.MessageType_MessageType_ResponseSkycoinAddress) {
-					addresses, err := deviceWallet.DecodeResponseSkycoinAddress(msg)
-					if err != nil {
-						log.Error(err)
-						return
-					}
-					log.Print("Successfully got address")
-					log.Print(addresses)
-				}
-			} else {
-				log.Println("Got addresses without pin code")
-				addresses, err := deviceWallet.DecodeResponseSkycoinAddress(msg)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-				log.Print(addresses)
+			addresses, err := deviceWallet.DecodeResponseSkycoinAddress(msg)
+			if err != nil {
+				return err
 			}
+			log.Print("Successfully got address")
+			log.Print(addresses)
+			return nil
 		},
 	}
 }
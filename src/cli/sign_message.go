@@ -3,12 +3,9 @@ package cli
 import (
 	"fmt"
 
-	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
-
 	gcli "github.com/urfave/cli"
 
 	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
-	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
 )
 
 func signMessageCmd() gcli.Command {
@@ -34,65 +31,22 @@ func signMessageCmd() gcli.Command {
 			},
 		},
 		OnUsageError: onCommandUsageError(name),
-		Action: func(c *gcli.Context) {
-			device := deviceWallet.NewDevice(deviceWallet.DeviceTypeFromString(c.String("deviceType")))
-			if device == nil {
-				return
+		Action: func(c *gcli.Context) (err error) {
+			device, finish, err := newDevice(c)
+			if err != nil {
+				return err
 			}
+			defer finishDevice(finish, &err)
 
 			addressN := c.Int("addressN")
 			message := c.String("message")
-			var signature string
 
-			var msg wire.Message
-			msg, err := device.SignMessage(addressN, message)
+			signature, err := deviceWallet.NewClient(device).SignMessage(addressN, message)
 			if err != nil {
-				log.Error(err)
-				return
-			}
-			for msg.Kind != uint16(messages.MessageType_MessageType_ResponseSkycoinSignMessage) && msg.Kind != uint16(messages.MessageType_MessageType_Failure) {
-				if msg.Kind == uint16(messages.MessageType_MessageType_PinMatrixRequest) {
-					var pinEnc string
-					fmt.Printf("PinMatrixRequest response: ")
-					fmt.Scanln(&pinEnc)
-					msg, err = device.PinMatrixAck(pinEnc)
-					if err != nil {
-						log.Error(err)
-						return
-					}
-					continue
-				}
-
-				if msg.Kind == uint16(messages.MessageType_MessageType_PassphraseRequest) {
-					var passphrase string
-					fmt.Printf("Input passphrase: ")
-					fmt.Scanln(&passphrase)
-					msg, err = device.PassphraseAck(passphrase)
-					if err != nil {
-						log.Error(err)
-						return
-					}
-					continue
-				}
-			}
-
-			if msg.Kind == uint16(messages.MessageType_MessageType_ResponseSkycoinSignMessage) {
-				signature, err = deviceWallet.DecodeResponseSkycoinSignMessage(msg)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-				fmt.Printf("Success %d! the signature is: %s\n", msg.Kind, signature)
-			} else {
-				failMsg, err := deviceWallet.DecodeFailMsg(msg)
-				if err != nil {
-					log.Error(err)
-					return
-				}
-
-				fmt.Printf("Failed with message: %s\n", failMsg)
-				return
+				return err
 			}
+			fmt.Printf("Success! the signature is: %s\n", signature)
+			return nil
 		},
 	}
 }
@@ -1,30 +1,38 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/gogo/protobuf/proto"
-
-	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+	"github.com/skycoin/skycoin/src/cipher"
 
 	gcli "github.com/urfave/cli"
 
 	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
 	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/transaction"
 )
 
 func transactionSignCmd() gcli.Command {
 	name := "transactionSign"
 	return gcli.Command{
-		Name:        name,
-		Usage:       "Ask the device to sign a transaction using the provided information.",
-		Description: "",
+		Name:  name,
+		Usage: "Ask the device to sign a transaction using the provided information.",
+		Description: `The transaction is given by the inputHash, inputIndex, outputAddress, coin, hour and addressIndex
+    flags, in the order of the inputs and outputs, by a JSON or CSV spec file with --spec, or by the hex of an
+    unsigned transaction with --unsignedTransaction.`,
 		Flags: []gcli.Flag{
 			gcli.StringSliceFlag{
 				Name:  "inputHash",
 				Usage: "Hash of the Input of the transaction we expect the device to sign",
 			},
-			gcli.IntSliceFlag{
+			gcli.StringSliceFlag{
 				Name:  "inputIndex",
 				Usage: "Index of the input in the wallet",
 			},
@@ -32,18 +40,44 @@ func transactionSignCmd() gcli.Command {
 				Name:  "outputAddress",
 				Usage: "Addresses of the output for the transaction",
 			},
-			gcli.Int64SliceFlag{
+			gcli.StringSliceFlag{
 				Name:  "coin",
-				Usage: "Amount of coins",
+				Usage: "Amount of coins, in droplets or in SKY with the unit, e.g. 1500000 or \"1.5 SKY\"",
 			},
-			gcli.Int64SliceFlag{
+			gcli.StringSliceFlag{
 				Name:  "hour",
 				Usage: "Number of hours",
 			},
-			gcli.IntSliceFlag{
+			gcli.StringSliceFlag{
 				Name:  "addressIndex",
 				Usage: "If the address is a return address tell its index in the wallet",
 			},
+			gcli.StringFlag{
+				Name:  "unsignedTransaction",
+				Usage: "Hex encoded unsigned transaction, as returned by createRawTransaction, the signed transaction is printed. Every input needs an inputIndex",
+			},
+			gcli.StringSliceFlag{
+				Name:  "change",
+				Usage: "Change output of the unsignedTransaction, as OUTPUT:ADDRESS_INDEX the output number, 0 based, and the index of its address in the wallet",
+			},
+			gcli.StringFlag{
+				Name:  "spec",
+				Usage: "JSON or CSV file describing the inputs and outputs of the transaction, - reads the standard input, the signed transaction is printed",
+			},
+			gcli.StringFlag{
+				Name:  "specFormat",
+				Usage: "Format of the spec, json or csv, by default csv for a .csv file and json otherwise",
+			},
+			gcli.StringSliceFlag{
+				Name:  "owner",
+				Usage: "Address owning an input of the unsignedTransaction or spec, as INPUT_HASH:ADDRESS, the signatures are verified against them instead of the addresses of the wallet at the inputIndex indexes",
+			},
+			gcli.StringFlag{
+				Name:  "uxouts",
+				Usage: "JSON file listing the uxouts spent by the inputs, the coins and coin hours of the outputs are validated against them",
+			},
+			headTimeFlag,
+			burnFactorFlag,
 			gcli.StringFlag{
 				Name:   "deviceType",
 				Usage:  "Device type to send instructions to, hardware wallet (USB) or emulator.",
@@ -51,27 +85,46 @@ func transactionSignCmd() gcli.Command {
 			},
 		},
 		OnUsageError: onCommandUsageError(name),
-		Action: func(c *gcli.Context) {
+		Action: func(c *gcli.Context) (err error) {
 			inputs := c.StringSlice("inputHash")
-			inputIndex := c.IntSlice("inputIndex")
 			outputs := c.StringSlice("outputAddress")
-			coins := c.Int64Slice("coin")
-			hours := c.Int64Slice("hour")
-			addressIndex := c.IntSlice("addressIndex")
+			coins := c.StringSlice("coin")
+			inputIndex, err := parseUints("inputIndex", c.StringSlice("inputIndex"), 32)
+			if err != nil {
+				return err
+			}
+			hours, err := parseUints("hour", c.StringSlice("hour"), 64)
+			if err != nil {
+				return err
+			}
+			addressIndex, err := parseUints("addressIndex", c.StringSlice("addressIndex"), 32)
+			if err != nil {
+				return err
+			}
 
-			device := deviceWallet.NewDevice(deviceWallet.DeviceTypeFromString(c.String("deviceType")))
-			if device == nil {
-				return
+			device, finish, err := newDevice(c)
+			if err != nil {
+				return err
+			}
+			defer finishDevice(finish, &err)
+			validation, err := validateOptions(c)
+			if err != nil {
+				return err
+			}
+
+			if spec := c.String("spec"); spec != "" {
+				return signSpec(device, spec, c.String("specFormat"), c.StringSlice("owner"), validation)
+			}
+			if unsigned := c.String("unsignedTransaction"); unsigned != "" {
+				return signRawTransaction(device, unsigned, inputIndex, c.StringSlice("change"), c.StringSlice("owner"), validation)
 			}
 
 			fmt.Println(inputs, inputIndex)
 			if len(inputs) != len(inputIndex) {
-				fmt.Println("Every given input hash should have the an inputIndex")
-				return
+				return errors.New("every given input hash should have the an inputIndex")
 			}
 			if len(outputs) != len(coins) || len(outputs) != len(hours) {
-				fmt.Println("Every given output should have a coin and hour value")
-				return
+				return errors.New("every given output should have a coin and hour value")
 			}
 			fmt.Println(outputs, coins, hours, addressIndex)
 			var transactionInputs []*messages.SkycoinTransactionInput
@@ -83,74 +136,260 @@ func transactionSignCmd() gcli.Command {
 				transactionInputs = append(transactionInputs, &transactionInput)
 			}
 			for i, output := range outputs {
+				if _, err := cipher.DecodeBase58Address(output); err != nil {
+					return fmt.Errorf("invalid output address %q: %v", output, err)
+				}
+				coin, err := transaction.ParseCoins(coins[i])
+				if err != nil {
+					return err
+				}
 				var transactionOutput messages.SkycoinTransactionOutput
 				transactionOutput.Address = proto.String(output)
-				transactionOutput.Coin = proto.Uint64(uint64(coins[i]))
-				transactionOutput.Hour = proto.Uint64(uint64(hours[i]))
+				transactionOutput.Coin = proto.Uint64(coin)
+				transactionOutput.Hour = proto.Uint64(hours[i])
 				if i < len(addressIndex) {
 					transactionOutput.AddressIndex = proto.Uint32(uint32(addressIndex[i]))
 				}
 				transactionOutputs = append(transactionOutputs, &transactionOutput)
 			}
 
-			var msg wire.Message
-			msg, err := device.TransactionSign(transactionInputs, transactionOutputs)
+			txn, inputIndexes, err := messagesTransaction(transactionInputs, transactionOutputs)
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
-
-			for {
-				switch msg.Kind {
-				case uint16(messages.MessageType_MessageType_ResponseTransactionSign):
-					signatures, err := deviceWallet.DecodeResponseTransactionSign(msg)
-					if err != nil {
-						log.Error(err)
-						return
-					}
-					fmt.Println(signatures)
-					return
-				case uint16(messages.MessageType_MessageType_Success):
-					fmt.Println("Should end with ResponseTransactionSign request")
-					return
-				case uint16(messages.MessageType_MessageType_ButtonRequest):
-					msg, err = device.ButtonAck()
-					if err != nil {
-						log.Error(err)
-						return
-					}
-				case uint16(messages.MessageType_MessageType_PassphraseRequest):
-					var passphrase string
-					fmt.Printf("Input passphrase: ")
-					fmt.Scanln(&passphrase)
-					msg, err = device.PassphraseAck(passphrase)
-					if err != nil {
-						log.Error(err)
-						return
-					}
-				case uint16(messages.MessageType_MessageType_PinMatrixRequest):
-					var pinEnc string
-					fmt.Printf("PinMatrixRequest response: ")
-					fmt.Scanln(&pinEnc)
-					msg, err = device.PinMatrixAck(pinEnc)
-					if err != nil {
-						log.Error(err)
-						return
-					}
-				case uint16(messages.MessageType_MessageType_Failure):
-					failMsg, err := deviceWallet.DecodeFailMsg(msg)
-					if err != nil {
-						log.Error(err)
-						return
-					}
-
-					fmt.Printf("Failed with message: %s\n", failMsg)
-					return
-				default:
-					log.Errorf("received unexpected message type: %s", messages.MessageType(msg.Kind))
-					return
-				}
+			if err := transaction.Validate(txn, validation); err != nil {
+				return err
+			}
+			signatures, report, err := deviceWallet.NewClient(device).TransactionSign(transactionInputs, transactionOutputs)
+			printChangeReport(report)
+			if err != nil {
+				return err
+			}
+			if err := verifyTransactionSignatures(device, txn, inputIndexes, signatures); err != nil {
+				return err
 			}
+			fmt.Println(signatures)
+			return nil
 		},
 	}
 }
+
+// signRawTransaction has the device sign the hex encoded unsigned transaction and prints the signed transaction
+func signRawTransaction(device deviceWallet.Devicer, unsigned string, inputIndex []uint64, changes, owners []string,
+	validation *transaction.ValidateOptions) error {
+	txn, err := transaction.DeserializeHex(strings.TrimSpace(unsigned))
+	if err != nil {
+		return err
+	}
+
+	options := transaction.SignOptions{
+		Validation: validation,
+	}
+	if options.Owners, err = parseOwners(owners); err != nil {
+		return err
+	}
+	if options.ChangeIndexes, err = parseChanges(changes); err != nil {
+		return err
+	}
+	for _, index := range inputIndex {
+		options.InputIndexes = append(options.InputIndexes, uint32(index))
+	}
+	return signTransaction(device, txn, options)
+}
+
+// signSpec has the device sign the transaction described by the JSON or CSV spec file and prints the signed transaction
+func signSpec(device deviceWallet.Devicer, path, format string, owners []string, validation *transaction.ValidateOptions) error {
+	r := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if format == "" {
+		format = "json"
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			format = "csv"
+		}
+	}
+	var spec *transaction.Spec
+	var err error
+	switch strings.ToLower(format) {
+	case "json":
+		spec, err = transaction.ParseSpecJSON(r)
+	case "csv":
+		spec, err = transaction.ParseSpecCSV(r)
+	default:
+		return fmt.Errorf("invalid spec format %q, expecting json or csv", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	txn, options := spec.Transaction()
+	options.Validation = validation
+	if options.Owners, err = parseOwners(owners); err != nil {
+		return err
+	}
+	return signTransaction(device, txn, options)
+}
+
+// signTransaction has the device sign txn and prints the id and the hex of the signed transaction.
+// The transaction is validated before the device is used, to derive the change addresses or to sign.
+func signTransaction(device deviceWallet.Devicer, txn *transaction.Transaction, options transaction.SignOptions) error {
+	signed, report, err := transaction.Sign(device, txn, options)
+	printChangeReport(report)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Transaction id: %s\n", signed.Hash().Hex())
+	fmt.Println(signed.SerializeHex())
+	return nil
+}
+
+var (
+	headTimeFlag = gcli.Uint64Flag{
+		Name:  "headTime",
+		Usage: "Time, in unix seconds, of the head block of the blockchain, the coin hours of the uxouts are computed at it, required with --uxouts",
+	}
+	burnFactorFlag = gcli.UintFlag{
+		Name:  "burnFactor",
+		Value: transaction.DefaultBurnFactor,
+		Usage: "Burn factor of the coin hours, the inverse of the share of the input coin hours burned as fee",
+	}
+)
+
+// requireHeadTime returns the --headTime flag, which has to be given along with the uxouts
+func requireHeadTime(c *gcli.Context) (uint64, error) {
+	t := c.Uint64("headTime")
+	if t == 0 {
+		return 0, errors.New("the head time of the blockchain is given with --headTime, the coin hours of the uxouts are computed at it")
+	}
+	return t, nil
+}
+
+// validateOptions returns the options validating the coin hours of the transaction against the --uxouts file,
+// nil when no uxouts are given
+func validateOptions(c *gcli.Context) (*transaction.ValidateOptions, error) {
+	path := c.String("uxouts")
+	if path == "" {
+		return nil, nil
+	}
+	headTime, err := requireHeadTime(c)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	uxouts, err := transaction.ReadUxouts(f)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction.ValidateOptions{
+		Uxouts:     uxouts,
+		HeadTime:   headTime,
+		BurnFactor: uint32(c.Uint("burnFactor")),
+	}, nil
+}
+
+// printChangeReport prints whether each output goes back to the wallet or to a third party, the addresses of the
+// change outputs compared to the addresses derived by the device, when the outputs were verified
+func printChangeReport(report deviceWallet.ChangeReport) {
+	if report != nil {
+		fmt.Println(report)
+	}
+}
+
+// parseUints parses the unsigned integers of bitSize bits given to the flag
+func parseUints(flag string, values []string, bitSize int) ([]uint64, error) {
+	parsed := make([]uint64, len(values))
+	for i, value := range values {
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, bitSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s %q, expecting an unsigned integer of %d bits", flag, value, bitSize)
+		}
+		parsed[i] = n
+	}
+	return parsed, nil
+}
+
+// parseChanges parses the OUTPUT:ADDRESS_INDEX change outputs
+func parseChanges(changes []string) (map[int]uint32, error) {
+	parsed := make(map[int]uint32)
+	for _, change := range changes {
+		var output int
+		var index uint32
+		if _, err := fmt.Sscanf(change, "%d:%d", &output, &index); err != nil {
+			return nil, fmt.Errorf("invalid change %q, expecting OUTPUT:ADDRESS_INDEX", change)
+		}
+		parsed[output] = index
+	}
+	return parsed, nil
+}
+
+// parseOwners parses the INPUT_HASH:ADDRESS owners of the inputs, nil when none is given
+func parseOwners(owners []string) (transaction.Owners, error) {
+	if len(owners) == 0 {
+		return nil, nil
+	}
+	parsed := make(transaction.Owners)
+	for _, owner := range owners {
+		parts := strings.Split(owner, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid owner %q, expecting INPUT_HASH:ADDRESS", owner)
+		}
+		hash, err := cipher.SHA256FromHex(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner %q: %v", owner, err)
+		}
+		addr, err := cipher.DecodeBase58Address(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner %q: %v", owner, err)
+		}
+		parsed[hash] = addr
+	}
+	return parsed, nil
+}
+
+// messagesTransaction returns the unsigned transaction of the inputs and outputs and the indexes of the inputs
+func messagesTransaction(inputs []*messages.SkycoinTransactionInput, outputs []*messages.SkycoinTransactionOutput) (*transaction.Transaction, []uint32, error) {
+	txn := &transaction.Transaction{}
+	var inputIndexes []uint32
+	for _, input := range inputs {
+		hash, err := cipher.SHA256FromHex(input.GetHashIn())
+		if err != nil {
+			return nil, nil, fmt.Errorf("input %s: %v", input.GetHashIn(), err)
+		}
+		txn.In = append(txn.In, hash)
+		inputIndexes = append(inputIndexes, input.GetIndex())
+	}
+	for _, output := range outputs {
+		addr, err := cipher.DecodeBase58Address(output.GetAddress())
+		if err != nil {
+			return nil, nil, fmt.Errorf("output %s: %v", output.GetAddress(), err)
+		}
+		txn.Out = append(txn.Out, transaction.Output{Address: addr, Coins: output.GetCoin(), Hours: output.GetHour()})
+	}
+	txn.UpdateHeader()
+	return txn, inputIndexes, nil
+}
+
+// verifyTransactionSignatures checks the signatures returned by the device were made by the addresses
+// of the wallet at the indexes of the inputs
+func verifyTransactionSignatures(device deviceWallet.Devicer, txn *transaction.Transaction, inputIndexes []uint32, signatures []string) error {
+	signed, err := transaction.AddSignatures(txn, signatures)
+	if err != nil {
+		return err
+	}
+	owners, err := transaction.OwnersFromDevice(device, signed, inputIndexes)
+	if err != nil {
+		return err
+	}
+	return transaction.VerifySignatures(signed, owners)
+}
@@ -0,0 +1,110 @@
+package devicewallet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+)
+
+// ErrChangeAddress is returned when a change output is not sent to the address of the wallet at its AddressIndex
+var ErrChangeAddress = errors.New("change address mismatch")
+
+// OutputReport tells whether an output of a transaction goes back to the wallet or to a third party
+type OutputReport struct {
+	Output  int
+	Address string
+	// Coins in droplets
+	Coins uint64
+	Hours uint64
+	// Change is set for the outputs with an AddressIndex, claimed to go back to the wallet
+	Change       bool
+	AddressIndex uint32
+	// Derived is the address of the wallet at AddressIndex, empty for the outputs to third parties
+	Derived string
+}
+
+// Mismatch reports whether the output is claimed as change but not sent to the address of the wallet at its index
+func (r OutputReport) Mismatch() bool {
+	return r.Change && r.Derived != r.Address
+}
+
+func (r OutputReport) String() string {
+	s := fmt.Sprintf("output %d: %d droplets, %d hours to %s", r.Output, r.Coins, r.Hours, r.Address)
+	switch {
+	case !r.Change:
+		return s + ", third party"
+	case r.Mismatch():
+		return fmt.Sprintf("%s, MISMATCH the wallet address at index %d is %s", s, r.AddressIndex, r.Derived)
+	default:
+		return fmt.Sprintf("%s, change to the wallet address at index %d", s, r.AddressIndex)
+	}
+}
+
+// ChangeReport tells where each output of a transaction goes
+type ChangeReport []OutputReport
+
+// Mismatches returns the change outputs not sent to the address of the wallet at their index
+func (r ChangeReport) Mismatches() ChangeReport {
+	var mismatches ChangeReport
+	for _, output := range r {
+		if output.Mismatch() {
+			mismatches = append(mismatches, output)
+		}
+	}
+	return mismatches
+}
+
+func (r ChangeReport) String() string {
+	lines := make([]string, len(r))
+	for i, output := range r {
+		lines[i] = output.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// VerifyChange derives with AddressGen the wallet addresses at the AddressIndex of the change outputs and compares
+// them to the output addresses. The report is returned along with an ErrChangeAddress error on mismatch.
+func (c *Client) VerifyChange(outputs []*messages.SkycoinTransactionOutput) (ChangeReport, error) {
+	derived := make(map[uint32]string)
+	report := make(ChangeReport, len(outputs))
+	for i, output := range outputs {
+		report[i] = OutputReport{
+			Output:  i,
+			Address: output.GetAddress(),
+			Coins:   output.GetCoin(),
+			Hours:   output.GetHour(),
+		}
+		if output.AddressIndex == nil {
+			continue
+		}
+
+		index := output.GetAddressIndex()
+		address, ok := derived[index]
+		if !ok {
+			addresses, err := c.AddressGen(1, int(index), false)
+			if err != nil {
+				return nil, err
+			}
+			if len(addresses) != 1 {
+				return nil, fmt.Errorf("%d addresses derived at index %d", len(addresses), index)
+			}
+			address = addresses[0]
+			derived[index] = address
+		}
+		report[i].Change = true
+		report[i].AddressIndex = index
+		report[i].Derived = address
+	}
+
+	if mismatches := report.Mismatches(); len(mismatches) != 0 {
+		msgs := make([]string, len(mismatches))
+		for i, output := range mismatches {
+			msgs[i] = fmt.Sprintf("output %d to %s, the wallet address at index %d is %s",
+				output.Output, output.Address, output.AddressIndex, output.Derived)
+		}
+		return report, fmt.Errorf("%w: %s", ErrChangeAddress, strings.Join(msgs, "; "))
+	}
+	return report, nil
+}
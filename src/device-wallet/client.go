@@ -0,0 +1,98 @@
+package devicewallet
+
+import (
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+// Client provides typed results on top of the wire.Message based Devicer api.
+// A device answering with Failure makes the methods return a FailureError.
+type Client struct {
+	Devicer Devicer
+}
+
+// NewClient returns a Client using the given device
+func NewClient(device Devicer) *Client {
+	return &Client{
+		Devicer: device,
+	}
+}
+
+// GetFeatures returns the device features
+func (c *Client) GetFeatures() (*messages.Features, error) {
+	msg, err := c.Devicer.GetFeatures()
+	if err != nil {
+		return nil, err
+	}
+
+	features := &messages.Features{}
+	if err := decodeResponse(msg, messages.MessageType_MessageType_Features, features); err != nil {
+		return nil, err
+	}
+	return features, nil
+}
+
+// AddressGen returns addressN addresses starting from startIndex
+func (c *Client) AddressGen(addressN, startIndex int, confirmAddress bool) ([]string, error) {
+	msg, err := c.Devicer.AddressGen(addressN, startIndex, confirmAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &messages.ResponseSkycoinAddress{}
+	if err := decodeResponse(msg, messages.MessageType_MessageType_ResponseSkycoinAddress, response); err != nil {
+		return nil, err
+	}
+	return response.GetAddresses(), nil
+}
+
+// SignMessage returns the signature of message by the address at addressIndex
+func (c *Client) SignMessage(addressIndex int, message string) (string, error) {
+	msg, err := c.Devicer.SignMessage(addressIndex, message)
+	if err != nil {
+		return "", err
+	}
+
+	response := &messages.ResponseSkycoinSignMessage{}
+	if err := decodeResponse(msg, messages.MessageType_MessageType_ResponseSkycoinSignMessage, response); err != nil {
+		return "", err
+	}
+	return response.GetSignedMessage(), nil
+}
+
+// TransactionSign returns the signatures of the transaction inputs and the report of its outputs.
+// The change outputs are verified first, the transaction is not signed on mismatch and the report
+// is returned with an ErrChangeAddress error, see VerifyChange.
+func (c *Client) TransactionSign(inputs []*messages.SkycoinTransactionInput, outputs []*messages.SkycoinTransactionOutput) ([]string, ChangeReport, error) {
+	report, err := c.VerifyChange(outputs)
+	if err != nil {
+		return nil, report, err
+	}
+
+	msg, err := c.Devicer.TransactionSign(inputs, outputs)
+	if err != nil {
+		return nil, report, err
+	}
+
+	response := &messages.ResponseTransactionSign{}
+	if err := decodeResponse(msg, messages.MessageType_MessageType_ResponseTransactionSign, response); err != nil {
+		return nil, report, err
+	}
+	return response.GetSignatures(), report, nil
+}
+
+// decodeResponse unmarshals msg into response when it has the expected kind
+func decodeResponse(msg wire.Message, kind messages.MessageType, response proto.Message) error {
+	switch msg.Kind {
+	case uint16(kind):
+		return proto.Unmarshal(msg.Data, response)
+	case uint16(messages.MessageType_MessageType_Failure):
+		return decodeFailure(msg)
+	default:
+		return fmt.Errorf("received unexpected message type %s, expecting %s", messages.MessageType(msg.Kind), kind)
+	}
+}
@@ -0,0 +1,105 @@
+package devicewallet
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+func testHelperMessage(t *testing.T, kind messages.MessageType, pb proto.Message) wire.Message {
+	data, err := proto.Marshal(pb)
+	require.NoError(t, err)
+	return wire.Message{Kind: uint16(kind), Data: data}
+}
+
+func TestClientAddressGen(t *testing.T) {
+	devicer := &MockDevicer{}
+	devicer.On("AddressGen", 2, 0, false).Return(testHelperMessage(t,
+		messages.MessageType_MessageType_ResponseSkycoinAddress,
+		&messages.ResponseSkycoinAddress{
+			Addresses: []string{"2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw", "zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs"},
+		}), nil)
+
+	addresses, err := NewClient(devicer).AddressGen(2, 0, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw", "zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs"}, addresses)
+	devicer.AssertExpectations(t)
+}
+
+func TestClientFailure(t *testing.T) {
+	devicer := &MockDevicer{}
+	devicer.On("SignMessage", 1, "Hello World!").Return(testHelperMessage(t,
+		messages.MessageType_MessageType_Failure,
+		&messages.Failure{
+			Code:    messages.FailureType_Failure_NotInitialized.Enum(),
+			Message: proto.String("Device not initialized"),
+		}), nil)
+
+	_, err := NewClient(devicer).SignMessage(1, "Hello World!")
+	require.Equal(t, FailureError{
+		Code:    messages.FailureType_Failure_NotInitialized,
+		Message: "Device not initialized",
+	}, err)
+	require.True(t, errors.Is(err, ErrNotInitialized))
+	require.False(t, errors.Is(err, ErrPinInvalid))
+
+	var failure FailureError
+	require.True(t, errors.As(err, &failure))
+	require.Equal(t, messages.FailureType_Failure_NotInitialized, failure.Code)
+}
+
+func TestClientUnexpectedMessage(t *testing.T) {
+	devicer := &MockDevicer{}
+	devicer.On("GetFeatures").Return(testHelperMessage(t,
+		messages.MessageType_MessageType_Success,
+		&messages.Success{}), nil)
+
+	_, err := NewClient(devicer).GetFeatures()
+	require.Error(t, err)
+	_, ok := err.(FailureError)
+	require.False(t, ok)
+}
+
+func TestClientVerifyChange(t *testing.T) {
+	devicer := &MockDevicer{}
+	devicer.On("AddressGen", 1, 1, false).Return(testHelperMessage(t,
+		messages.MessageType_MessageType_ResponseSkycoinAddress,
+		&messages.ResponseSkycoinAddress{Addresses: []string{"zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs"}}), nil).Once()
+	inputs := []*messages.SkycoinTransactionInput{{
+		HashIn: proto.String("181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9"),
+		Index:  proto.Uint32(0),
+	}}
+	outputs := []*messages.SkycoinTransactionOutput{
+		{Address: proto.String("K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot"), Coin: proto.Uint64(100000), Hour: proto.Uint64(2)},
+		{Address: proto.String("zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs"), Coin: proto.Uint64(1000), Hour: proto.Uint64(1), AddressIndex: proto.Uint32(1)},
+	}
+
+	report, err := NewClient(devicer).VerifyChange(outputs)
+	require.NoError(t, err)
+	require.Equal(t, ChangeReport{
+		{Output: 0, Address: "K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot", Coins: 100000, Hours: 2},
+		{Output: 1, Address: "zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs", Coins: 1000, Hours: 1,
+			Change: true, AddressIndex: 1, Derived: "zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs"},
+	}, report)
+	require.Empty(t, report.Mismatches())
+	require.Equal(t, `output 0: 100000 droplets, 2 hours to K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot, third party
+output 1: 1000 droplets, 1 hours to zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs, change to the wallet address at index 1`, report.String())
+
+	// a change output not sent to the wallet address at its index is refused before signing
+	devicer.On("AddressGen", 1, 0, false).Return(testHelperMessage(t,
+		messages.MessageType_MessageType_ResponseSkycoinAddress,
+		&messages.ResponseSkycoinAddress{Addresses: []string{"2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw"}}), nil).Once()
+	outputs[1].AddressIndex = proto.Uint32(0)
+	_, report, err = NewClient(devicer).TransactionSign(inputs, outputs)
+	require.True(t, errors.Is(err, ErrChangeAddress))
+	require.Len(t, report.Mismatches(), 1)
+	require.EqualError(t, err, "change address mismatch: output 1 to zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs, "+
+		"the wallet address at index 0 is 2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw")
+	devicer.AssertExpectations(t)
+	devicer.AssertNotCalled(t, "TransactionSign", inputs, outputs)
+}
@@ -0,0 +1,325 @@
+package devicewallet
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+// cancelTimeout is how long a cancelled operation is given to read the device answer
+// to the Cancel message before its connection is closed
+var cancelTimeout = 5 * time.Second
+
+// errCancelled is returned when writing a message on a connection after a Cancel was sent on it
+var errCancelled = errors.New("operation cancelled")
+
+// conn is a device connection on which a Cancel message can be written while an operation is in progress.
+// The messages written are kept whole, a Cancel is never inserted between the reports of another message.
+// The connection is broken when a read or write fails or a report read is not part of a message,
+// the reports exchanged afterwards could belong to another message.
+type conn struct {
+	io.ReadWriteCloser
+
+	mu sync.Mutex
+	// remaining bytes of the message being written, only used by the goroutine running the operation
+	remaining int
+	// unread bytes of the message being read, only used by the goroutine running the operation
+	unread int
+	// failed is set when the connection is broken, only used by the goroutine running the operation
+	failed bool
+	// cancelled is set once a Cancel is sent, the messages written afterwards are refused, guarded by mu
+	cancelled bool
+}
+
+func newConn(dev io.ReadWriteCloser) *conn {
+	return &conn{
+		ReadWriteCloser: dev,
+	}
+}
+
+func (c *conn) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if c.remaining <= 0 {
+		c.mu.Lock()
+		if c.cancelled {
+			c.mu.Unlock()
+			c.failed = true
+			return 0, errCancelled
+		}
+		if len(p) >= 9 && p[0] == '?' && p[1] == '#' && p[2] == '#' {
+			// "##", kind and size followed by the message payload
+			c.remaining = 8 + int(binary.BigEndian.Uint32(p[5:9]))
+		}
+	}
+
+	n, err := c.ReadWriteCloser.Write(p)
+	if err != nil {
+		c.failed = true
+	}
+	// every report starts with '?'
+	c.remaining -= len(p) - 1
+	if err != nil || c.remaining <= 0 {
+		c.remaining = 0
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+func (c *conn) Read(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(p)
+	switch {
+	case err != nil:
+		c.failed = true
+	case c.unread <= 0 && n >= 9 && p[0] == '?' && p[1] == '#' && p[2] == '#':
+		c.unread = int(binary.BigEndian.Uint32(p[5:9])) - (n - 9)
+	case c.unread > 0 && n > 0 && p[0] == '?':
+		c.unread -= n - 1
+	default:
+		c.failed = true
+	}
+	return n, err
+}
+
+// broken reports whether the connection is broken or in the middle of a message
+func (c *conn) broken() bool {
+	return c.failed || c.unread > 0 || c.remaining > 0
+}
+
+// cancel writes a Cancel message once the message being written, if any, is complete
+func (c *conn) cancel() error {
+	chunks, err := MessageCancel()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancelled = true
+	return sendToDeviceNoAnswer(c.ReadWriteCloser, chunks)
+}
+
+// connection returns the connection used by the ongoing operation
+func (d *Device) connection() *conn {
+	d.devMu.Lock()
+	defer d.devMu.Unlock()
+	c, _ := d.dev.(*conn)
+	return c
+}
+
+// setContext records ctx as the context of the ongoing operation and returns the previous one
+func (d *Device) setContext(ctx context.Context) context.Context {
+	d.devMu.Lock()
+	defer d.devMu.Unlock()
+	prev := d.ctx
+	d.ctx = ctx
+	return prev
+}
+
+// operationContext returns the context of the ongoing operation, nil when it is not run by withContext
+func (d *Device) operationContext() context.Context {
+	d.devMu.Lock()
+	defer d.devMu.Unlock()
+	return d.ctx
+}
+
+// contextErr returns the error of the context of the ongoing operation once it is done
+func (d *Device) contextErr() error {
+	d.devMu.Lock()
+	defer d.devMu.Unlock()
+	if d.ctx == nil {
+		return nil
+	}
+	return d.ctx.Err()
+}
+
+// withContext runs call until it returns or ctx is done.
+// When ctx is done the operation is not allowed to connect to the device anymore and, if it is connected,
+// a Cancel message is sent to the device and no other message is written on the connection. call is given
+// cancelTimeout to read the device answer, after which the connection is closed. withContext always waits
+// for call to return, so that a cancelled operation does not reach the device afterwards, and returns ctx.Err().
+// An operation waiting for the Interactor returns as soon as ctx is done, see interact.
+func (d *Device) withContext(ctx context.Context, call func() (wire.Message, error)) (wire.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return wire.Message{}, err
+	}
+
+	type result struct {
+		msg wire.Message
+		err error
+	}
+	prev := d.setContext(ctx)
+	done := make(chan result, 1)
+	go func() {
+		msg, err := call()
+		done <- result{msg, err}
+	}()
+
+	select {
+	case r := <-done:
+		d.setContext(prev)
+		return r.msg, r.err
+	case <-ctx.Done():
+	}
+
+	// an operation connecting from now on sees ctx done, see acquire
+	c := d.connection()
+	if c == nil {
+		<-done
+		d.setContext(prev)
+		return wire.Message{}, ctx.Err()
+	}
+	if err := c.cancel(); err != nil {
+		log.Errorf("sending %s: %v", messages.MessageType_MessageType_Cancel, err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(cancelTimeout):
+		if err := c.Close(); err != nil {
+			log.Errorf("closing connection: %v", err)
+		}
+		<-done
+	}
+	d.setContext(prev)
+
+	// the connection of a session refuses the messages after the Cancel, the next operation connects again
+	if d.connection() == c {
+		if err := d.disconnect(); err != nil {
+			log.Errorf("closing connection: %v", err)
+		}
+	}
+	return wire.Message{}, ctx.Err()
+}
+
+// AddressGenContext is AddressGen bound to ctx
+func (d *Device) AddressGenContext(ctx context.Context, addressN, startIndex int, confirmAddress bool) (wire.Message, error) {
+	return d.withContext(ctx, func() (wire.Message, error) {
+		return d.AddressGen(addressN, startIndex, confirmAddress)
+	})
+}
+
+// ApplySettingsContext is ApplySettings bound to ctx
+func (d *Device) ApplySettingsContext(ctx context.Context, usePassphrase bool, label string) (wire.Message, error) {
+	return d.withContext(ctx, func() (wire.Message, error) {
+		return d.ApplySettings(usePassphrase, label)
+	})
+}
+
+// BackupContext is Backup bound to ctx
+func (d *Device) BackupContext(ctx context.Context) (wire.Message, error) {
+	return d.withContext(ctx, d.Backup)
+}
+
+// CancelContext is Cancel bound to ctx
+func (d *Device) CancelContext(ctx context.Context) (wire.Message, error) {
+	return d.withContext(ctx, d.Cancel)
+}
+
+// CheckMessageSignatureContext is CheckMessageSignature bound to ctx
+func (d *Device) CheckMessageSignatureContext(ctx context.Context, message, signature, address string) (wire.Message, error) {
+	return d.withContext(ctx, func() (wire.Message, error) {
+		return d.CheckMessageSignature(message, signature, address)
+	})
+}
+
+// ChangePinContext is ChangePin bound to ctx
+func (d *Device) ChangePinContext(ctx context.Context) (wire.Message, error) {
+	return d.withContext(ctx, d.ChangePin)
+}
+
+// ConnectedContext is Connected bound to ctx, it returns false when ctx is done first
+func (d *Device) ConnectedContext(ctx context.Context) bool {
+	connected := false
+	_, err := d.withContext(ctx, func() (wire.Message, error) {
+		connected = d.Connected()
+		return wire.Message{}, nil
+	})
+	return err == nil && connected
+}
+
+// FirmwareUploadContext is FirmwareUpload bound to ctx
+func (d *Device) FirmwareUploadContext(ctx context.Context, payload []byte, hash [32]byte) error {
+	_, err := d.withContext(ctx, func() (wire.Message, error) {
+		return wire.Message{}, d.FirmwareUpload(payload, hash)
+	})
+	return err
+}
+
+// GetFeaturesContext is GetFeatures bound to ctx
+func (d *Device) GetFeaturesContext(ctx context.Context) (wire.Message, error) {
+	return d.withContext(ctx, d.GetFeatures)
+}
+
+// GenerateMnemonicContext is GenerateMnemonic bound to ctx
+func (d *Device) GenerateMnemonicContext(ctx context.Context, wordCount uint32, usePassphrase bool) (wire.Message, error) {
+	return d.withContext(ctx, func() (wire.Message, error) {
+		return d.GenerateMnemonic(wordCount, usePassphrase)
+	})
+}
+
+// RecoveryContext is Recovery bound to ctx
+func (d *Device) RecoveryContext(ctx context.Context, wordCount uint32, usePassphrase, dryRun bool) (wire.Message, error) {
+	return d.withContext(ctx, func() (wire.Message, error) {
+		return d.Recovery(wordCount, usePassphrase, dryRun)
+	})
+}
+
+// SetMnemonicContext is SetMnemonic bound to ctx
+func (d *Device) SetMnemonicContext(ctx context.Context, mnemonic string) (wire.Message, error) {
+	return d.withContext(ctx, func() (wire.Message, error) {
+		return d.SetMnemonic(mnemonic)
+	})
+}
+
+// TransactionSignContext is TransactionSign bound to ctx
+func (d *Device) TransactionSignContext(ctx context.Context, inputs []*messages.SkycoinTransactionInput, outputs []*messages.SkycoinTransactionOutput) (wire.Message, error) {
+	return d.withContext(ctx, func() (wire.Message, error) {
+		return d.TransactionSign(inputs, outputs)
+	})
+}
+
+// SignMessageContext is SignMessage bound to ctx
+func (d *Device) SignMessageContext(ctx context.Context, addressIndex int, message string) (wire.Message, error) {
+	return d.withContext(ctx, func() (wire.Message, error) {
+		return d.SignMessage(addressIndex, message)
+	})
+}
+
+// WipeContext is Wipe bound to ctx
+func (d *Device) WipeContext(ctx context.Context) (wire.Message, error) {
+	return d.withContext(ctx, d.Wipe)
+}
+
+// PinMatrixAckContext is PinMatrixAck bound to ctx
+func (d *Device) PinMatrixAckContext(ctx context.Context, p string) (wire.Message, error) {
+	return d.withContext(ctx, func() (wire.Message, error) {
+		return d.PinMatrixAck(p)
+	})
+}
+
+// WordAckContext is WordAck bound to ctx
+func (d *Device) WordAckContext(ctx context.Context, word string) (wire.Message, error) {
+	return d.withContext(ctx, func() (wire.Message, error) {
+		return d.WordAck(word)
+	})
+}
+
+// PassphraseAckContext is PassphraseAck bound to ctx
+func (d *Device) PassphraseAckContext(ctx context.Context, passphrase string) (wire.Message, error) {
+	return d.withContext(ctx, func() (wire.Message, error) {
+		return d.PassphraseAck(passphrase)
+	})
+}
+
+// ButtonAckContext is ButtonAck bound to ctx
+func (d *Device) ButtonAckContext(ctx context.Context) (wire.Message, error) {
+	return d.withContext(ctx, d.ButtonAck)
+}
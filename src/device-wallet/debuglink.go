@@ -0,0 +1,221 @@
+package devicewallet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/usb"
+)
+
+// DebugLinkPortOffset is the offset of the DebugLink port of an emulator from its main port
+const DebugLinkPortOffset = 1
+
+// ErrNoDebugLink is returned when the device has no DebugLink, only the emulator and the simulator have one
+var ErrNoDebugLink = errors.New("the device has no DebugLink")
+
+// DebugLinkDriver is implemented by the drivers giving access to the DebugLink of their device
+type DebugLinkDriver interface {
+	GetDebugLink() (io.ReadWriteCloser, error)
+}
+
+// GetDebugLink returns a connection to the DebugLink of the emulator, which listens on the port
+// following the one of the emulator. The usb.UDP bus reaching it is kept by the driver, as the
+// emulator bus is, see getBus.
+func (drv *Driver) GetDebugLink() (io.ReadWriteCloser, error) {
+	if drv.DeviceType() != DeviceTypeEmulator || len(drv.emulatorPorts) == 0 {
+		return nil, ErrNoDebugLink
+	}
+
+	port := drv.emulatorPorts[0]
+	if p, err := strconv.Atoi(strings.TrimPrefix(drv.selection.Path, "emulator")); err == nil && drv.selection.Path != "" {
+		port = p
+	}
+	port += DebugLinkPortOffset
+
+	if drv.debugLink == nil {
+		udp, err := usb.InitUDP(drv.emulatorHost, []int{port})
+		if err != nil {
+			return nil, err
+		}
+		drv.debugLink = udp
+	}
+	return drv.debugLink.Connect(fmt.Sprintf("emulator%d", port))
+}
+
+// DebugLink returns the DebugLink of the device, ErrNoDebugLink when its driver has none
+func (d *Device) DebugLink() (*DebugLink, error) {
+	drv, ok := d.Driver.(DebugLinkDriver)
+	if !ok {
+		return nil, ErrNoDebugLink
+	}
+	dev, err := drv.GetDebugLink()
+	if err != nil {
+		return nil, err
+	}
+	return NewDebugLink(dev), nil
+}
+
+// DebugLink is the debug channel of the emulator, used to automate the tests.
+// It takes the button decisions and reads the device state, e.g. the PIN matrix layout.
+type DebugLink struct {
+	mu  sync.Mutex
+	dev io.ReadWriteCloser
+}
+
+// NewDebugLink returns the DebugLink talking on dev
+func NewDebugLink(dev io.ReadWriteCloser) *DebugLink {
+	return &DebugLink{
+		dev: dev,
+	}
+}
+
+// Decision presses the button confirming, yes, or cancelling the operation waiting for the user
+func (l *DebugLink) Decision(yes bool) error {
+	chunks, err := EncodeMessage(&messages.DebugLinkDecision{
+		YesNo: proto.Bool(yes),
+	})
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return sendToDeviceNoAnswer(l.dev, chunks)
+}
+
+// State returns the device state
+func (l *DebugLink) State() (*messages.DebugLinkState, error) {
+	chunks, err := EncodeMessage(&messages.DebugLinkGetState{})
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	msg, err := sendToDevice(l.dev, chunks, DefaultLimits())
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &messages.DebugLinkState{}
+	if err := decodeResponse(msg, messages.MessageType_MessageType_DebugLinkState, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Matrix returns the layout of the PIN matrix displayed by the device, the digit at position i is
+// the one selected by sending the character '1'+i, see EncodePin
+func (l *DebugLink) Matrix() (string, error) {
+	state, err := l.State()
+	if err != nil {
+		return "", err
+	}
+	if state.GetMatrix() == "" {
+		return "", errors.New("no PIN matrix displayed")
+	}
+	return state.GetMatrix(), nil
+}
+
+// Stop stops the emulator
+func (l *DebugLink) Stop() error {
+	chunks, err := EncodeMessage(&messages.DebugLinkStop{})
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return sendToDeviceNoAnswer(l.dev, chunks)
+}
+
+// Close closes the DebugLink
+func (l *DebugLink) Close() error {
+	return l.dev.Close()
+}
+
+// EncodePin returns the positions of the digits of pin on matrix, as sent in PinMatrixAck
+func EncodePin(matrix, pin string) (string, error) {
+	positions := make([]byte, len(pin))
+	for i := 0; i < len(pin); i++ {
+		j := strings.IndexByte(matrix, pin[i])
+		if j < 0 {
+			return "", fmt.Errorf("digit %q missing from the PIN matrix", pin[i])
+		}
+		positions[i] = byte('1' + j)
+	}
+	return string(positions), nil
+}
+
+// DebugInteractor is an Interactor answering the device requests through its DebugLink,
+// so the operations asking for the PIN, the seed words or buttons run unattended in the tests
+type DebugInteractor struct {
+	Link *DebugLink
+	// Pin entered on the PIN matrix
+	Pin string
+	// Passphrase of the wallet
+	Passphrase string
+	// Mnemonic typed during the recovery, the word asked is found in the device state
+	Mnemonic string
+	// Reject cancels the operations instead of confirming them
+	Reject bool
+
+	// Words displayed by the device during the backup, in order
+	Words []string
+}
+
+// RequestPin returns Pin encoded on the matrix displayed by the device
+func (i *DebugInteractor) RequestPin(messages.PinMatrixRequestType) (string, error) {
+	if i.Pin == "" {
+		return "", errors.New("no PIN")
+	}
+	matrix, err := i.Link.Matrix()
+	if err != nil {
+		return "", err
+	}
+	return EncodePin(matrix, i.Pin)
+}
+
+// RequestPassphrase returns Passphrase
+func (i *DebugInteractor) RequestPassphrase() (string, error) {
+	return i.Passphrase, nil
+}
+
+// RequestWord returns the word of Mnemonic asked by the device, or the fake word it asks for
+func (i *DebugInteractor) RequestWord() (string, error) {
+	state, err := i.Link.State()
+	if err != nil {
+		return "", err
+	}
+	if fake := state.GetRecoveryFakeWord(); fake != "" {
+		return fake, nil
+	}
+
+	words := strings.Fields(i.Mnemonic)
+	pos := int(state.GetRecoveryWordPos())
+	if pos < 1 || pos > len(words) {
+		return "", fmt.Errorf("word %d asked, the mnemonic has %d words", pos, len(words))
+	}
+	return words[pos-1], nil
+}
+
+// OnButton keeps the word displayed during the backup, if any, and presses the button
+func (i *DebugInteractor) OnButton(code messages.ButtonRequestType) error {
+	if code == messages.ButtonRequestType_ButtonRequest_ConfirmWord {
+		state, err := i.Link.State()
+		if err != nil {
+			return err
+		}
+		if word := state.GetResetWord(); word != "" {
+			i.Words = append(i.Words, word)
+		}
+	}
+	return i.Link.Decision(!i.Reject)
+}
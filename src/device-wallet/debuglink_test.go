@@ -0,0 +1,43 @@
+package devicewallet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodePin(t *testing.T) {
+	pin, err := EncodePin("741852963", "1234")
+	require.NoError(t, err)
+	require.Equal(t, "3692", pin)
+
+	pin, err = EncodePin("123456789", "")
+	require.NoError(t, err)
+	require.Empty(t, pin)
+
+	_, err = EncodePin("12345678", "9")
+	require.EqualError(t, err, `digit '9' missing from the PIN matrix`)
+}
+
+func TestGetDebugLink(t *testing.T) {
+	_, err := newDriver(DeviceTypeUSB).GetDebugLink()
+	require.Equal(t, ErrNoDebugLink, err)
+
+	_, err = NewDevice(DeviceTypeUSB).DebugLink()
+	require.Equal(t, ErrNoDebugLink, err)
+
+	// the mocked drivers have no DebugLink
+	_, err = (&Device{Driver: &MockDeviceDriver{}}).DebugLink()
+	require.Equal(t, ErrNoDebugLink, err)
+
+	// the DebugLink bus is created once per driver
+	drv := newDriver(DeviceTypeEmulator, WithEmulator("127.0.0.1", 21324))
+	dev, err := drv.GetDebugLink()
+	require.NoError(t, err)
+	require.NoError(t, dev.Close())
+	bus := drv.debugLink
+	require.NotNil(t, bus)
+	_, err = drv.GetDebugLink()
+	require.NoError(t, err)
+	require.True(t, bus == drv.debugLink)
+}
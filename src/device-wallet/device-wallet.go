@@ -1,11 +1,12 @@
 package devicewallet
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"io"
-	"time"
+	"sync"
 
+	"github.com/gogo/protobuf/proto"
 	"github.com/skycoin/skycoin/src/util/logging"
 
 	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
@@ -36,6 +37,7 @@ const (
 //go:generate mockery -name Devicer -case underscore -inpkg -testonly
 
 // Devicer provides api for the hw wallet functions
+// The Context variants cancel the ongoing operation on the device when ctx is done
 type Devicer interface {
 	AddressGen(addressN, startIndex int, confirmAddress bool) (wire.Message, error)
 	ApplySettings(usePassphrase bool, label string) (wire.Message, error)
@@ -56,7 +58,33 @@ type Devicer interface {
 	WordAck(word string) (wire.Message, error)
 	PassphraseAck(passphrase string) (wire.Message, error)
 	ButtonAck() (wire.Message, error)
+	SendMessage(msg wire.Message) (wire.Message, error)
+	Call(req proto.Message) (proto.Message, error)
 	SetAutoPressButton(simulateButtonPress bool, simulateButtonType ButtonType) error
+	SetInteractor(interactor Interactor)
+	OpenSession() error
+	CloseSession() error
+
+	AddressGenContext(ctx context.Context, addressN, startIndex int, confirmAddress bool) (wire.Message, error)
+	ApplySettingsContext(ctx context.Context, usePassphrase bool, label string) (wire.Message, error)
+	BackupContext(ctx context.Context) (wire.Message, error)
+	CancelContext(ctx context.Context) (wire.Message, error)
+	CheckMessageSignatureContext(ctx context.Context, message, signature, address string) (wire.Message, error)
+	ChangePinContext(ctx context.Context) (wire.Message, error)
+	ConnectedContext(ctx context.Context) bool
+	FirmwareUploadContext(ctx context.Context, payload []byte, hash [32]byte) error
+	GetFeaturesContext(ctx context.Context) (wire.Message, error)
+	GenerateMnemonicContext(ctx context.Context, wordCount uint32, usePassphrase bool) (wire.Message, error)
+	RecoveryContext(ctx context.Context, wordCount uint32, usePassphrase, dryRun bool) (wire.Message, error)
+	SetMnemonicContext(ctx context.Context, mnemonic string) (wire.Message, error)
+	TransactionSignContext(ctx context.Context, inputs []*messages.SkycoinTransactionInput, outputs []*messages.SkycoinTransactionOutput) (wire.Message, error)
+	SignMessageContext(ctx context.Context, addressIndex int, message string) (wire.Message, error)
+	WipeContext(ctx context.Context) (wire.Message, error)
+	PinMatrixAckContext(ctx context.Context, p string) (wire.Message, error)
+	WordAckContext(ctx context.Context, word string) (wire.Message, error)
+	PassphraseAckContext(ctx context.Context, passphrase string) (wire.Message, error)
+	ButtonAckContext(ctx context.Context) (wire.Message, error)
+	CallContext(ctx context.Context, req proto.Message) (proto.Message, error)
 }
 
 // Device provides hardware wallet functions
@@ -66,10 +94,22 @@ type Device struct {
 	// dev latest device connection instance
 	// during an ongoing operation the device instance cannot be requested before closing the previous instance
 	// keeping the connection instance in the struct helps with closing and opening of the connection
-	dev io.ReadWriteCloser
+	dev   io.ReadWriteCloser
+	devMu sync.Mutex
+
+	// session keeps dev open between operations, see OpenSession
+	session bool
+	// depth is the number of nested operations using dev
+	depth int
+	// ctx is the context of the operation run by withContext, guarded by devMu.
+	// No connection is opened for the operation once it is done, see acquire.
+	ctx context.Context
 
 	simulateButtonPress bool
 	simulateButtonType  ButtonType
+
+	// interactor answers the device requests, see SetInteractor
+	interactor Interactor
 }
 
 // DeviceTypeFromString returns device type from string
@@ -89,15 +129,14 @@ func DeviceTypeFromString(deviceType string) DeviceType {
 	return dtRet
 }
 
-// NewDevice returns a new device instance
-func NewDevice(deviceType DeviceType) (device *Device) {
+// NewDevice returns a new device instance, the options choose which device to connect to
+// when several are attached
+func NewDevice(deviceType DeviceType, options ...Option) (device *Device) {
 	switch deviceType {
 	case DeviceTypeUSB, DeviceTypeEmulator:
 		device = &Device{
-			&Driver{deviceType},
-			nil,
-			false,
-			ButtonType(-1),
+			Driver:             newDriver(deviceType, options...),
+			simulateButtonType: ButtonType(-1),
 		}
 	default:
 		device = nil
@@ -117,46 +156,58 @@ func (d *Device) Connect() error {
 		return err
 	}
 
-	d.dev = dev
+	d.devMu.Lock()
+	d.dev = newConn(dev)
+	d.devMu.Unlock()
 	return nil
 }
 
 // AddressGen Ask the device to generate an address
 func (d *Device) AddressGen(addressN, startIndex int, confirmAddress bool) (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 
 	chunks, err := MessageAddressGen(addressN, startIndex, confirmAddress)
 	if err != nil {
 		return wire.Message{}, err
 	}
 
-	return d.Driver.SendToDevice(d.dev, chunks)
+	msg, err := d.Driver.SendToDevice(d.dev, chunks)
+	if err != nil {
+		return wire.Message{}, err
+	}
+
+	return d.interact(msg)
 }
 
 // ApplySettings send ApplySettings request to the device
 func (d *Device) ApplySettings(usePassphrase bool, label string) (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 
 	chunks, err := MessageApplySettings(usePassphrase, label)
 	if err != nil {
 		return wire.Message{}, err
 	}
 
-	return d.Driver.SendToDevice(d.dev, chunks)
+	msg, err := d.Driver.SendToDevice(d.dev, chunks)
+	if err != nil {
+		return wire.Message{}, err
+	}
+
+	return d.interact(msg)
 }
 
 // Backup ask the device to perform the seed backup
 func (d *Device) Backup() (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 	var msg wire.Message
 
 	var chunks [][64]byte
@@ -175,22 +226,15 @@ func (d *Device) Backup() (wire.Message, error) {
 		return wire.Message{}, err
 	}
 
-	for msg.Kind == uint16(messages.MessageType_MessageType_ButtonRequest) {
-		msg, err = d.ButtonAck()
-		if err != nil {
-			return wire.Message{}, err
-		}
-	}
-
-	return msg, nil
+	return d.interact(msg)
 }
 
 // Cancel sends a Cancel request
 func (d *Device) Cancel() (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 	chunks, err := MessageCancel()
 	if err != nil {
 		return wire.Message{}, err
@@ -201,10 +245,10 @@ func (d *Device) Cancel() (wire.Message, error) {
 
 // CheckMessageSignature Check a message signature matches the given address.
 func (d *Device) CheckMessageSignature(message, signature, address string) (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 
 	// Send CheckMessageSignature
 	chunks, err := MessageCheckMessageSignature(message, signature, address)
@@ -232,10 +276,10 @@ func (d *Device) CheckMessageSignature(message, signature, address string) (wire
 // top, bottom-right, top-left, right, top-right
 // so you must send "83769".
 func (d *Device) ChangePin() (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 	chunks, err := MessageChangePin()
 	if err != nil {
 		return wire.Message{}, err
@@ -246,99 +290,34 @@ func (d *Device) ChangePin() (wire.Message, error) {
 		return wire.Message{}, err
 	}
 
-	// Acknowledge that a button has been pressed
-	if msg.Kind == uint16(messages.MessageType_MessageType_ButtonRequest) {
-		msg, err = d.ButtonAck()
-		if err != nil {
-			return msg, err
-		}
-	}
-
-	return msg, nil
+	return d.interact(msg)
 }
 
 // Connected check if a device is connected
 func (d *Device) Connected() bool {
-	dev, err := d.Driver.GetDevice()
-	if dev == nil {
-		return false
-	}
-	defer dev.Close()
-	if err != nil {
+	if err := d.acquire(); err != nil {
 		return false
 	}
+	defer d.release()
 
 	chunks, err := MessageConnected()
 	if err != nil {
 		log.Error(err)
 		return false
 	}
-	for _, element := range chunks {
-		_, err = dev.Write(element[:])
-		if err != nil {
-			return false
-		}
-	}
-	var msg wire.Message
-	_, err = msg.ReadFrom(dev)
+	msg, err := d.Driver.SendToDevice(d.dev, chunks)
 	if err != nil {
 		return false
 	}
 	return msg.Kind == uint16(messages.MessageType_MessageType_Success)
 }
 
-// FirmwareUpload Updates device's firmware
-func (d *Device) FirmwareUpload(payload []byte, hash [32]byte) error {
-	if d.Driver.DeviceType() != DeviceTypeUSB {
-		return errors.New("wrong device type")
-	}
-	if err := d.Connect(); err != nil {
-		return err
-	}
-	defer d.dev.Close()
-
-	if err := Initialize(d.dev); err != nil {
-		return err
-	}
-
-	log.Printf("Length of firmware %d", uint32(len(payload)))
-
-	chunks, err := MessageFirmwareErase(payload)
-	if err != nil {
-		return err
-	}
-	erasemsg, err := d.Driver.SendToDevice(d.dev, chunks)
-	if err != nil {
-		return err
-	}
-	log.Printf("Success %d! FirmwareErase %s\n", erasemsg.Kind, erasemsg.Data)
-
-	log.Printf("Hash: %x\n", hash)
-
-	chunks, err = MessageFirmwareUpload(payload, hash)
-	if err != nil {
-		return err
-	}
-	uploadmsg, err := d.Driver.SendToDevice(d.dev, chunks)
-	if err != nil {
-		return err
-	}
-	log.Printf("Success %d! FirmwareUpload %s\n", uploadmsg.Kind, uploadmsg.Data)
-
-	// Send ButtonAck
-	chunks, err = MessageButtonAck()
-	if err != nil {
-		return err
-	}
-	return d.Driver.SendToDeviceNoAnswer(d.dev, chunks)
-}
-
 // GetFeatures send Features message to the device
 func (d *Device) GetFeatures() (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 	chunks, err := MessageGetFeatures()
 	if err != nil {
 		return wire.Message{}, err
@@ -349,10 +328,10 @@ func (d *Device) GetFeatures() (wire.Message, error) {
 
 // GenerateMnemonic Ask the device to generate a mnemonic and configure itself with it.
 func (d *Device) GenerateMnemonic(wordCount uint32, usePassphrase bool) (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 	generateMnemonicChunks, err := MessageGenerateMnemonic(wordCount, usePassphrase)
 	if err != nil {
 		return wire.Message{}, err
@@ -362,10 +341,7 @@ func (d *Device) GenerateMnemonic(wordCount uint32, usePassphrase bool) (wire.Me
 		return msg, err
 	}
 
-	switch msg.Kind {
-	case uint16(messages.MessageType_MessageType_ButtonRequest):
-		return d.ButtonAck()
-	case uint16(messages.MessageType_MessageType_EntropyRequest):
+	if msg.Kind == uint16(messages.MessageType_MessageType_EntropyRequest) {
 		chunks, err := MessageEntropyAck(entropyBufferSize)
 		if err != nil {
 			return wire.Message{}, err
@@ -380,15 +356,15 @@ func (d *Device) GenerateMnemonic(wordCount uint32, usePassphrase bool) (wire.Me
 		}
 	}
 
-	return msg, err
+	return d.interact(msg)
 }
 
 // Recovery ask the device to perform the seed backup
 func (d *Device) Recovery(wordCount uint32, usePassphrase, dryRun bool) (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 	var msg wire.Message
 	var chunks [][64]byte
 
@@ -403,22 +379,15 @@ func (d *Device) Recovery(wordCount uint32, usePassphrase, dryRun bool) (wire.Me
 	}
 	log.Printf("Recovery device %d! Answer is: %s\n", msg.Kind, msg.Data)
 
-	if msg.Kind == uint16(messages.MessageType_MessageType_ButtonRequest) {
-		msg, err = d.ButtonAck()
-		if err != nil {
-			return wire.Message{}, err
-		}
-	}
-
-	return msg, nil
+	return d.interact(msg)
 }
 
 // SetMnemonic Configure the device with a mnemonic.
 func (d *Device) SetMnemonic(mnemonic string) (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 
 	// Send SetMnemonic
 	chunks, err := MessageSetMnemonic(mnemonic)
@@ -430,49 +399,50 @@ func (d *Device) SetMnemonic(mnemonic string) (wire.Message, error) {
 		return wire.Message{}, err
 	}
 
-	if msg.Kind == uint16(messages.MessageType_MessageType_ButtonRequest) {
-		msg, err = d.ButtonAck()
-		if err != nil {
-			return wire.Message{}, err
-		}
-	}
-
-	return msg, err
+	return d.interact(msg)
 }
 
 // SignMessage Ask the device to sign a message using the secret key at given index.
 func (d *Device) SignMessage(addressIndex int, message string) (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 
 	chunks, err := MessageSignMessage(addressIndex, message)
 	if err != nil {
 		return wire.Message{}, err
 	}
-	return d.Driver.SendToDevice(d.dev, chunks)
+	msg, err := d.Driver.SendToDevice(d.dev, chunks)
+	if err != nil {
+		return wire.Message{}, err
+	}
+	return d.interact(msg)
 }
 
 // TransactionSign Ask the device to sign a transaction using the given information.
 func (d *Device) TransactionSign(inputs []*messages.SkycoinTransactionInput, outputs []*messages.SkycoinTransactionOutput) (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 	chunks, err := MessageTransactionSign(inputs, outputs)
 	if err != nil {
 		return wire.Message{}, err
 	}
-	return d.Driver.SendToDevice(d.dev, chunks)
+	msg, err := d.Driver.SendToDevice(d.dev, chunks)
+	if err != nil {
+		return wire.Message{}, err
+	}
+	return d.interact(msg)
 }
 
 // Wipe wipes out device configuration
 func (d *Device) Wipe() (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 	var chunks [][64]byte
 
 	err := Initialize(d.dev)
@@ -492,24 +462,17 @@ func (d *Device) Wipe() (wire.Message, error) {
 	}
 	log.Printf("Wipe device %d! Answer is: %x\n", msg.Kind, msg.Data)
 
-	if msg.Kind == uint16(messages.MessageType_MessageType_ButtonRequest) {
-		msg, err = d.ButtonAck()
-		if err != nil {
-			return wire.Message{}, err
-		}
-	}
-
-	return msg, err
+	return d.interact(msg)
 }
 
 // ButtonAck when the device is waiting for the user to press a button
 // the PC need to acknowledge, showing it knows we are waiting for a user action
 func (d *Device) ButtonAck() (wire.Message, error) {
 	var msg wire.Message
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 
 	// Send ButtonAck
 	chunks, err := MessageButtonAck()
@@ -528,16 +491,15 @@ func (d *Device) ButtonAck() (wire.Message, error) {
 		}
 	}
 
-	_, err = msg.ReadFrom(d.dev)
-	return msg, err
+	return readMessage(d.dev, d.limits())
 }
 
 // PassphraseAck send this message when the device is waiting for the user to input a passphrase
 func (d *Device) PassphraseAck(passphrase string) (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 	chunks, err := MessagePassphraseAck(passphrase)
 	if err != nil {
 		return wire.Message{}, err
@@ -547,10 +509,10 @@ func (d *Device) PassphraseAck(passphrase string) (wire.Message, error) {
 
 // WordAck send a word to the device during device "recovery procedure"
 func (d *Device) WordAck(word string) (wire.Message, error) {
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 	chunks, err := MessageWordAck(word)
 	if err != nil {
 		return wire.Message{}, err
@@ -565,11 +527,10 @@ func (d *Device) WordAck(word string) (wire.Message, error) {
 
 // PinMatrixAck during PIN code setting use this message to send user input to device
 func (d *Device) PinMatrixAck(p string) (wire.Message, error) {
-	time.Sleep(1 * time.Second)
-	if err := d.Connect(); err != nil {
+	if err := d.acquire(); err != nil {
 		return wire.Message{}, err
 	}
-	defer d.dev.Close()
+	defer d.release()
 
 	log.Printf("Setting pin: %s\n", p)
 
@@ -580,6 +541,21 @@ func (d *Device) PinMatrixAck(p string) (wire.Message, error) {
 	return d.Driver.SendToDevice(d.dev, chunks)
 }
 
+// SendMessage sends msg as is to the device and returns its answer.
+// The device requests are not answered, the caller has to send the acks, e.g. to relay the messages of another process.
+func (d *Device) SendMessage(msg wire.Message) (wire.Message, error) {
+	if err := d.acquire(); err != nil {
+		return wire.Message{}, err
+	}
+	defer d.release()
+
+	chunks, err := frameMessage(msg.Data, messages.MessageType(msg.Kind))
+	if err != nil {
+		return wire.Message{}, err
+	}
+	return d.Driver.SendToDevice(d.dev, chunks)
+}
+
 // SimulateButtonPress simulates a button press on emulator
 func (d *Device) SimulateButtonPress() error {
 	if d.Driver.DeviceType() != DeviceTypeEmulator {
@@ -0,0 +1,81 @@
+package devicewallet
+
+import (
+	"errors"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+var (
+	// ErrUnexpectedMessage the device did not expect the message it received
+	ErrUnexpectedMessage = errors.New("unexpected message")
+	// ErrDataError the request sent to the device is not valid
+	ErrDataError = errors.New("data error")
+	// ErrActionCancelled the user cancelled the operation on the device
+	ErrActionCancelled = errors.New("action cancelled")
+	// ErrPinInvalid the PIN does not match the device PIN
+	ErrPinInvalid = errors.New("PIN invalid")
+	// ErrPinMismatch the new PIN and its confirmation are different
+	ErrPinMismatch = errors.New("PIN mismatch")
+	// ErrInvalidSignature the signature does not match the message and address
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrNotInitialized the device has no seed configured
+	ErrNotInitialized = errors.New("device not initialized")
+	// ErrFirmwareError the device firmware failed processing the request
+	ErrFirmwareError = errors.New("firmware error")
+
+	failureErrors = map[messages.FailureType]error{
+		messages.FailureType_Failure_UnexpectedMessage: ErrUnexpectedMessage,
+		messages.FailureType_Failure_DataError:         ErrDataError,
+		messages.FailureType_Failure_ActionCancelled:   ErrActionCancelled,
+		messages.FailureType_Failure_PinCancelled:      ErrActionCancelled,
+		messages.FailureType_Failure_PinInvalid:        ErrPinInvalid,
+		messages.FailureType_Failure_PinMismatch:       ErrPinMismatch,
+		messages.FailureType_Failure_InvalidSignature:  ErrInvalidSignature,
+		messages.FailureType_Failure_NotInitialized:    ErrNotInitialized,
+		messages.FailureType_Failure_FirmwareError:     ErrFirmwareError,
+	}
+)
+
+// FailureError is returned when the device answers with a Failure message.
+// It matches the sentinel error of its code with errors.Is, e.g. errors.Is(err, ErrPinInvalid).
+type FailureError struct {
+	Code    messages.FailureType
+	Message string
+}
+
+func (e FailureError) Error() string {
+	if e.Message == "" {
+		return e.Code.String()
+	}
+	return e.Message
+}
+
+// Is reports whether target is the sentinel error of the failure code
+func (e FailureError) Is(target error) bool {
+	sentinel, ok := failureErrors[e.Code]
+	return ok && sentinel == target
+}
+
+// DecodeFailure returns the FailureError of a Failure message
+func DecodeFailure(msg wire.Message) (FailureError, error) {
+	pb, err := decodeKind(msg, messages.MessageType_MessageType_Failure, "DecodeFailure")
+	if err != nil {
+		return FailureError{}, err
+	}
+	failure := pb.(*messages.Failure)
+	return FailureError{
+		Code:    failure.GetCode(),
+		Message: failure.GetMessage(),
+	}, nil
+}
+
+// decodeFailure returns the FailureError of a Failure message, or the error decoding it
+func decodeFailure(msg wire.Message) error {
+	failure, err := DecodeFailure(msg)
+	if err != nil {
+		return err
+	}
+	return failure
+}
@@ -0,0 +1,177 @@
+/*
+Package firmware parses the firmware images uploaded to the device and verifies their signatures.
+
+An image starts with a 256 bytes header followed by the code:
+
+	0x00  magic "TRZR"
+	0x04  code length, uint32 little endian
+	0x08  key indexes of the 3 signatures, 1 based, 0 for an empty slot
+	0x0b  flags
+	0x0c  reserved
+	0x40  3 signatures of 64 bytes, over the SHA256 of the code
+	0x100 code
+
+The key indexes point in the list of the public keys trusted by the bootloader.
+The header does not hold the version of the firmware.
+*/
+package firmware
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+const (
+	// HeaderSize is the size of the header preceding the code
+	HeaderSize = 0x100
+	// SignatureSlots is the number of signatures of an image
+	SignatureSlots = 3
+	// SignatureSize is the size of a signature slot
+	SignatureSize = 64
+	// MaxCodeLength is the size of the flash left to the code after the bootloader, the metadata and the header
+	MaxCodeLength = 0x100000 - 0x10000 - HeaderSize
+
+	offsetCodeLength = 0x04
+	offsetKeyIndexes = 0x08
+	offsetFlags      = 0x0b
+	offsetSignatures = 0x40
+)
+
+// Magic starts the firmware images
+var Magic = []byte("TRZR")
+
+var (
+	// ErrTruncated is returned for an image shorter than its header
+	ErrTruncated = errors.New("firmware: image shorter than its header")
+	// ErrMagic is returned for an image not starting with Magic
+	ErrMagic = errors.New("firmware: wrong magic")
+	// ErrCodeLength is returned when the code length of the header does not match the image
+	ErrCodeLength = errors.New("firmware: wrong code length")
+)
+
+// Version of a firmware, the zero Version is unknown
+type Version struct {
+	Major uint8
+	Minor uint8
+	Patch uint8
+}
+
+func (v Version) String() string {
+	if v == (Version{}) {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// ParseVersion parses a version formatted as MAJOR.MINOR.PATCH
+func ParseVersion(s string) (Version, error) {
+	fields := strings.Split(s, ".")
+	if len(fields) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q, expected MAJOR.MINOR.PATCH", s)
+	}
+	var parts [3]uint8
+	for i, field := range fields {
+		part, err := strconv.ParseUint(field, 10, 8)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version %q, expected MAJOR.MINOR.PATCH", s)
+		}
+		parts[i] = uint8(part)
+	}
+	return Version{Major: parts[0], Minor: parts[1], Patch: parts[2]}, nil
+}
+
+// Header of a firmware image
+type Header struct {
+	CodeLength uint32
+	// KeyIndexes are the indexes, 1 based, of the keys of the signatures, 0 for an empty slot
+	KeyIndexes [SignatureSlots]uint8
+	Flags      uint8
+	Signatures [SignatureSlots][SignatureSize]byte
+}
+
+// Signed reports whether a signature slot is filled
+func (h Header) Signed() bool {
+	for _, index := range h.KeyIndexes {
+		if index != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Image is a parsed firmware image
+type Image struct {
+	Header
+	// Data is the whole image, header included, as uploaded to the device
+	Data []byte
+	// Version of the firmware, unknown unless set by the caller as the image does not hold it
+	Version Version
+}
+
+// Code returns the code following the header
+func (img *Image) Code() []byte {
+	return img.Data[HeaderSize:]
+}
+
+// Hash returns the SHA256 of the code, signed by the keys and sent with FirmwareUpload
+func (img *Image) Hash() [32]byte {
+	return cipher.SumSHA256(img.Code())
+}
+
+// String describes the image metadata, shown before erasing the firmware of the device
+func (img *Image) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Version: %s\n", img.Version)
+	fmt.Fprintf(&b, "Code length: %d\n", img.CodeLength)
+	fmt.Fprintf(&b, "Flags: 0x%02x\n", img.Flags)
+	fmt.Fprintf(&b, "Hash: %x\n", img.Hash())
+	for i, index := range img.KeyIndexes {
+		if index == 0 {
+			fmt.Fprintf(&b, "Signature %d: empty\n", i+1)
+			continue
+		}
+		fmt.Fprintf(&b, "Signature %d: key %d\n", i+1, index)
+	}
+	return b.String()
+}
+
+// ParseHeader parses the header of the image data
+func ParseHeader(data []byte) (Header, error) {
+	var h Header
+	if len(data) < HeaderSize {
+		return h, ErrTruncated
+	}
+	if !bytes.Equal(data[:len(Magic)], Magic) {
+		return h, ErrMagic
+	}
+
+	h.CodeLength = binary.LittleEndian.Uint32(data[offsetCodeLength:])
+	copy(h.KeyIndexes[:], data[offsetKeyIndexes:])
+	h.Flags = data[offsetFlags]
+	for i := range h.Signatures {
+		copy(h.Signatures[i][:], data[offsetSignatures+i*SignatureSize:])
+	}
+	return h, nil
+}
+
+// Parse parses the image data and checks the code length of its header matches the code following it
+func Parse(data []byte) (*Image, error) {
+	h, err := ParseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	codeLength := len(data) - HeaderSize
+	if codeLength == 0 || codeLength > MaxCodeLength || int(h.CodeLength) != codeLength {
+		return nil, fmt.Errorf("%w: %d bytes in the header, %d bytes of code", ErrCodeLength, h.CodeLength, codeLength)
+	}
+	return &Image{
+		Header: h,
+		Data:   data,
+	}, nil
+}
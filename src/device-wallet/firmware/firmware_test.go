@@ -0,0 +1,162 @@
+package firmware
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+// testKeys returns n deterministic key pairs
+func testKeys(t *testing.T, n int) ([]cipher.PubKey, []cipher.SecKey) {
+	secKeys := cipher.MustGenerateDeterministicKeyPairs([]byte("firmware"), n)
+	pubKeys := make([]cipher.PubKey, n)
+	for i, secKey := range secKeys {
+		pubKeys[i] = cipher.MustPubKeyFromSecKey(secKey)
+	}
+	return pubKeys, secKeys
+}
+
+// makeImage returns an image of the code signed by the keys of the indexes
+func makeImage(t *testing.T, code []byte, secKeys []cipher.SecKey, indexes ...uint8) []byte {
+	data := make([]byte, HeaderSize+len(code))
+	copy(data, Magic)
+	binary.LittleEndian.PutUint32(data[offsetCodeLength:], uint32(len(code)))
+	copy(data[HeaderSize:], code)
+
+	hash := cipher.SumSHA256(code)
+	for i, index := range indexes {
+		data[offsetKeyIndexes+i] = index
+		sig := cipher.MustSignHash(hash, secKeys[index-1])
+		copy(data[offsetSignatures+i*SignatureSize:], sig[:SignatureSize])
+	}
+	return data
+}
+
+func TestParse(t *testing.T) {
+	_, secKeys := testKeys(t, 3)
+	data := makeImage(t, []byte("code"), secKeys, 1, 2, 3)
+
+	img, err := Parse(data)
+	require.NoError(t, err)
+	require.Equal(t, uint32(4), img.CodeLength)
+	require.Equal(t, Version{}, img.Version)
+	require.Equal(t, [SignatureSlots]uint8{1, 2, 3}, img.KeyIndexes)
+	require.Equal(t, []byte("code"), img.Code())
+	require.Equal(t, [32]byte(cipher.SumSHA256([]byte("code"))), img.Hash())
+	require.Contains(t, img.String(), "Version: unknown\n")
+	img.Version = Version{Major: 1, Minor: 7, Patch: 2}
+	require.Contains(t, img.String(), "Version: 1.7.2\n")
+	require.Contains(t, img.String(), "Signature 3: key 3\n")
+
+	_, err = Parse(data[:HeaderSize-1])
+	require.Equal(t, ErrTruncated, err)
+
+	_, err = Parse(data[:HeaderSize+3])
+	require.True(t, errors.Is(err, ErrCodeLength))
+
+	_, err = Parse(data[:HeaderSize])
+	require.True(t, errors.Is(err, ErrCodeLength))
+
+	bad := append([]byte(nil), data...)
+	copy(bad, "TRZF")
+	_, err = Parse(bad)
+	require.Equal(t, ErrMagic, err)
+}
+
+func TestParseVersion(t *testing.T) {
+	version, err := ParseVersion("1.7.2")
+	require.NoError(t, err)
+	require.Equal(t, Version{Major: 1, Minor: 7, Patch: 2}, version)
+
+	for _, s := range []string{"", "1.7", "1.7.2.0", "1.x.2", "1.256.2", "1.-7.2"} {
+		_, err := ParseVersion(s)
+		require.EqualError(t, err, fmt.Sprintf("invalid version %q, expected MAJOR.MINOR.PATCH", s))
+	}
+}
+
+func TestVerify(t *testing.T) {
+	pubKeys, secKeys := testKeys(t, 5)
+	verifier := &Verifier{Keys: pubKeys}
+	code := []byte("the firmware code")
+
+	img, err := verifier.Check(makeImage(t, code, secKeys, 5, 1, 3))
+	require.NoError(t, err)
+	require.Equal(t, code, img.Code())
+
+	cases := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{
+			name: "unsigned",
+			data: makeImage(t, code, secKeys),
+			err:  ErrUnsigned,
+		},
+		{
+			name: "empty slot",
+			data: makeImage(t, code, secKeys, 1, 2),
+			err:  ErrKeyIndex,
+		},
+		{
+			name: "key used twice",
+			data: makeImage(t, code, secKeys, 1, 2, 1),
+			err:  ErrKeyIndex,
+		},
+		{
+			name: "unknown key",
+			data: func() []byte {
+				data := makeImage(t, code, secKeys, 1, 2, 3)
+				data[offsetKeyIndexes+2] = 6
+				return data
+			}(),
+			err: ErrKeyIndex,
+		},
+		{
+			name: "signature of another key",
+			data: func() []byte {
+				data := makeImage(t, code, secKeys, 1, 2, 3)
+				data[offsetKeyIndexes+2] = 4
+				return data
+			}(),
+			err: ErrSignature,
+		},
+		{
+			name: "tampered code",
+			data: func() []byte {
+				data := makeImage(t, code, secKeys, 1, 2, 3)
+				data[len(data)-1] ^= 1
+				return data
+			}(),
+			err: ErrSignature,
+		},
+		{
+			name: "malformed",
+			data: makeImage(t, code, secKeys, 1, 2, 3)[:HeaderSize+1],
+			err:  ErrCodeLength,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := verifier.Check(tc.data)
+			require.True(t, errors.Is(err, tc.err), "%v", err)
+		})
+	}
+
+	_, err = (&Verifier{}).Check(makeImage(t, code, secKeys, 1, 2, 3))
+	require.Equal(t, ErrNoTrustedKeys, err)
+}
+
+func TestNewVerifier(t *testing.T) {
+	pubKeys, _ := testKeys(t, 2)
+	verifier, err := NewVerifier([]string{pubKeys[0].Hex(), " " + pubKeys[1].Hex()})
+	require.NoError(t, err)
+	require.Equal(t, pubKeys, verifier.Keys)
+
+	_, err = NewVerifier([]string{"00"})
+	require.Error(t, err)
+}
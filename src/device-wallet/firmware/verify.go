@@ -0,0 +1,91 @@
+package firmware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// TrustedKeysEnv is the environment variable read by the CLI for the hex public keys trusted by the bootloader, comma separated
+const TrustedKeysEnv = "FIRMWARE_TRUSTED_KEYS"
+
+var (
+	// ErrUnsigned is returned for an image without signature
+	ErrUnsigned = errors.New("firmware: image not signed")
+	// ErrNoTrustedKeys is returned when the signatures are verified without trusted keys
+	ErrNoTrustedKeys = errors.New("firmware: no trusted keys")
+	// ErrKeyIndex is returned for a signature slot empty, or pointing to a missing or already used key
+	ErrKeyIndex = errors.New("firmware: invalid key index")
+	// ErrSignature is returned for a signature not made by its key
+	ErrSignature = errors.New("firmware: invalid signature")
+)
+
+// Verifier verifies the signatures of the images against the keys trusted by the bootloader
+type Verifier struct {
+	// Keys in the order of the bootloader, the key indexes of the header point in them
+	Keys []cipher.PubKey
+}
+
+// NewVerifier returns a Verifier trusting the hex encoded public keys
+func NewVerifier(keys []string) (*Verifier, error) {
+	v := &Verifier{}
+	for _, key := range keys {
+		pubKey, err := cipher.PubKeyFromHex(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("trusted key %q: %v", key, err)
+		}
+		v.Keys = append(v.Keys, pubKey)
+	}
+	return v, nil
+}
+
+// Verify checks every signature slot holds a signature of the image hash by a distinct trusted key
+func (v *Verifier) Verify(img *Image) error {
+	if !img.Signed() {
+		return ErrUnsigned
+	}
+	if len(v.Keys) == 0 {
+		return ErrNoTrustedKeys
+	}
+
+	hash := cipher.SHA256(img.Hash())
+	used := make(map[uint8]bool)
+	for i, index := range img.KeyIndexes {
+		if index == 0 || int(index) > len(v.Keys) || used[index] {
+			return fmt.Errorf("%w: signature %d, key %d", ErrKeyIndex, i+1, index)
+		}
+		used[index] = true
+
+		if !verifySignature(v.Keys[index-1], img.Signatures[i], hash) {
+			return fmt.Errorf("%w: signature %d, key %d", ErrSignature, i+1, index)
+		}
+	}
+	return nil
+}
+
+// verifySignature verifies a signature without its recovery id, trying each of them
+func verifySignature(pubKey cipher.PubKey, signature [SignatureSize]byte, hash cipher.SHA256) bool {
+	var sig cipher.Sig
+	copy(sig[:], signature[:])
+	for recID := byte(0); recID < 4; recID++ {
+		sig[SignatureSize] = recID
+		if cipher.VerifyPubKeySignedHash(pubKey, sig, hash) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Check parses the image data and verifies its signatures
+func (v *Verifier) Check(data []byte) (*Image, error) {
+	img, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.Verify(img); err != nil {
+		return img, err
+	}
+	return img, nil
+}
@@ -0,0 +1,263 @@
+package devicewallet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/firmware"
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/usb"
+)
+
+const (
+	// DefaultRestartTimeout is the time given to the device to restart on its new firmware
+	DefaultRestartTimeout = 30 * time.Second
+	// defaultPollInterval is the interval between the attempts to reconnect to the restarting device
+	defaultPollInterval = 500 * time.Millisecond
+)
+
+var (
+	// ErrNotInBootloader is returned when updating the firmware of a device not running its bootloader
+	ErrNotInBootloader = errors.New("the device is not in bootloader mode, unplug it and plug it back holding both buttons")
+	// ErrVersionMismatch is returned when the device restarts with another version than the one of the image
+	ErrVersionMismatch = errors.New("firmware version mismatch")
+	// ErrRestartTimeout is returned when the device does not come back on its new firmware in time
+	ErrRestartTimeout = errors.New("timeout waiting for the device to restart")
+
+	// errNoProductID is returned by Driver.Bootloader for the devices not attached through USB,
+	// or selected by their Features
+	errNoProductID = errors.New("no USB product id")
+)
+
+// UpdateStage is a step of the firmware update
+type UpdateStage int
+
+const (
+	// UpdateStageErase the firmware of the device is being erased
+	UpdateStageErase UpdateStage = iota + 1
+	// UpdateStageUpload the image is being written to the device
+	UpdateStageUpload
+	// UpdateStageConfirm the device waits for the user to confirm the hash of the image
+	UpdateStageConfirm
+	// UpdateStageRestart the firmware is installed, the device restarts on it
+	UpdateStageRestart
+	// UpdateStageDone the device runs the version of the image
+	UpdateStageDone
+)
+
+func (s UpdateStage) String() string {
+	switch s {
+	case UpdateStageErase:
+		return "erase"
+	case UpdateStageUpload:
+		return "upload"
+	case UpdateStageConfirm:
+		return "confirm"
+	case UpdateStageRestart:
+		return "restart"
+	case UpdateStageDone:
+		return "done"
+	default:
+		return fmt.Sprintf("UpdateStage(%d)", int(s))
+	}
+}
+
+// UpdateProgress is reported during the firmware update
+type UpdateProgress struct {
+	Stage UpdateStage
+	// Sent and Total are the bytes of the upload reports written to the device and to write
+	Sent  int
+	Total int
+}
+
+// UpdateOptions configure UpdateFirmware
+type UpdateOptions struct {
+	// Progress is called at every stage and for every report uploaded, when set
+	Progress func(UpdateProgress)
+	// RestartTimeout is the time given to the device to restart, DefaultRestartTimeout when zero
+	RestartTimeout time.Duration
+}
+
+// BootloaderDriver is implemented by the drivers telling whether the device runs its bootloader
+type BootloaderDriver interface {
+	Bootloader() (bool, error)
+}
+
+// Bootloader reports whether the selected USB device runs its bootloader according to its product id.
+// The label and device id of a selection are only known from the device Features, a device selected
+// by them is not matched on its USB identity, Device.Bootloader asks it for its Features instead.
+func (drv *Driver) Bootloader() (bool, error) {
+	if drv.DeviceType() != DeviceTypeUSB || drv.selection.needsFeatures() {
+		return false, errNoProductID
+	}
+
+	b, err := drv.getBus()
+	if err != nil {
+		return false, err
+	}
+	infos, err := b.Enumerate()
+	if err != nil {
+		return false, err
+	}
+	for _, info := range infos {
+		if drv.selection.matchInfo(info) {
+			return info.Bootloader(), nil
+		}
+	}
+	return false, usb.ErrNotFound
+}
+
+// Bootloader reports whether the device runs its bootloader, see BootloaderDriver.
+// The devices whose driver can not tell, e.g. the emulators, are asked for their Features.
+func (d *Device) Bootloader() (bool, error) {
+	if drv, ok := d.Driver.(BootloaderDriver); ok {
+		bootloader, err := drv.Bootloader()
+		if err != errNoProductID {
+			return bootloader, err
+		}
+	}
+	features, err := NewClient(d).GetFeatures()
+	if err != nil {
+		return false, err
+	}
+	return features.GetBootloaderMode(), nil
+}
+
+// FirmwareUpload erases the firmware of the device, which has to run its bootloader, and uploads payload.
+// The device asks the user to confirm hash, the SHA256 of the code, see firmware.Image.Hash.
+// The device then restarts, see UpdateFirmware to wait for it.
+func (d *Device) FirmwareUpload(payload []byte, hash [32]byte) error {
+	return d.firmwareUpload(payload, hash, func(UpdateProgress) {})
+}
+
+func (d *Device) firmwareUpload(payload []byte, hash [32]byte, progress func(UpdateProgress)) error {
+	bootloader, err := d.Bootloader()
+	if err != nil {
+		return err
+	}
+	if !bootloader {
+		return ErrNotInBootloader
+	}
+
+	if err := d.acquire(); err != nil {
+		return err
+	}
+	defer d.release()
+
+	progress(UpdateProgress{Stage: UpdateStageErase})
+	chunks, err := MessageFirmwareErase(payload)
+	if err != nil {
+		return err
+	}
+	msg, err := d.Driver.SendToDevice(d.dev, chunks)
+	if err != nil {
+		return err
+	}
+	if msg, err = d.interact(msg); err != nil {
+		return err
+	}
+	if _, err := DecodeSuccessOrFailMsg(msg); err != nil {
+		return fmt.Errorf("erasing the firmware: %w", err)
+	}
+
+	chunks, err = MessageFirmwareUpload(payload, hash)
+	if err != nil {
+		return err
+	}
+	total := len(chunks) * len(chunks[0])
+	progress(UpdateProgress{Stage: UpdateStageUpload, Total: total})
+	msg, err = d.Driver.SendToDevice(&progressWriter{
+		ReadWriteCloser: d.dev,
+		total:           total,
+		progress:        progress,
+	}, chunks)
+	if err != nil {
+		return err
+	}
+
+	if msg.Kind == uint16(messages.MessageType_MessageType_ButtonRequest) {
+		progress(UpdateProgress{Stage: UpdateStageConfirm})
+	}
+	if msg, err = d.interact(msg); err != nil {
+		return err
+	}
+	if _, err := DecodeSuccessOrFailMsg(msg); err != nil {
+		return fmt.Errorf("uploading the firmware: %w", err)
+	}
+	return nil
+}
+
+// UpdateFirmware uploads img to the device running its bootloader, waits for the device to restart
+// and returns its Features. The version the device restarts on is checked against the version of img,
+// unless the image has no version.
+func (d *Device) UpdateFirmware(img *firmware.Image, options UpdateOptions) (*messages.Features, error) {
+	progress := options.Progress
+	if progress == nil {
+		progress = func(UpdateProgress) {}
+	}
+	timeout := options.RestartTimeout
+	if timeout == 0 {
+		timeout = DefaultRestartTimeout
+	}
+
+	if err := d.firmwareUpload(img.Data, img.Hash(), progress); err != nil {
+		return nil, err
+	}
+
+	// the connection of a session did not survive the restart, the next operation connects again
+	if err := d.disconnect(); err != nil {
+		log.Errorf("closing connection: %v", err)
+	}
+
+	progress(UpdateProgress{Stage: UpdateStageRestart})
+	features, err := d.waitRestart(timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	version := firmware.Version{
+		Major: uint8(features.GetMajorVersion()),
+		Minor: uint8(features.GetMinorVersion()),
+		Patch: uint8(features.GetPatchVersion()),
+	}
+	if img.Version != (firmware.Version{}) && version != img.Version {
+		return features, fmt.Errorf("%w: the device runs %s, the image is %s", ErrVersionMismatch, version, img.Version)
+	}
+	progress(UpdateProgress{Stage: UpdateStageDone})
+	return features, nil
+}
+
+// waitRestart reconnects to the device until it answers out of bootloader mode
+func (d *Device) waitRestart(timeout time.Duration) (*messages.Features, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		features, err := NewClient(d).GetFeatures()
+		if err == nil && !features.GetBootloaderMode() {
+			return features, nil
+		}
+		if time.Now().After(deadline) {
+			if err == nil {
+				err = errors.New("the device is still in bootloader mode")
+			}
+			return nil, fmt.Errorf("%w: %v", ErrRestartTimeout, err)
+		}
+		time.Sleep(defaultPollInterval)
+	}
+}
+
+// progressWriter reports the bytes written to the device
+type progressWriter struct {
+	io.ReadWriteCloser
+	sent     int
+	total    int
+	progress func(UpdateProgress)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.ReadWriteCloser.Write(p)
+	w.sent += n
+	w.progress(UpdateProgress{Stage: UpdateStageUpload, Sent: w.sent, Total: w.total})
+	return n, err
+}
@@ -1,15 +1,9 @@
 package devicewallet
 
 import (
-	"bytes"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
-	"net"
-	"time"
-
-	"github.com/gogo/protobuf/proto"
 
 	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
 	"github.com/skycoin/hardware-wallet-go/src/device-wallet/usb"
@@ -52,6 +46,16 @@ type DeviceDriver interface {
 // Driver represents a particular device (USB / Emulator)
 type Driver struct {
 	deviceType DeviceType
+	selection  Selection
+	// bus the devices are attached to, see getBus
+	bus *usb.USB
+	// emulatorHost and emulatorPorts locate the emulators, see WithEmulator
+	emulatorHost  string
+	emulatorPorts []int
+	// limits of the messages read from the device, see Limits
+	limits *wire.Limits
+	// debugLink is the bus of the DebugLink of the emulator, see GetDebugLink
+	debugLink *usb.UDP
 }
 
 // DeviceType return driver device type
@@ -66,19 +70,17 @@ func (drv *Driver) SendToDeviceNoAnswer(dev io.ReadWriteCloser, chunks [][64]byt
 
 // SendToDevice sends msg to device and returns response
 func (drv *Driver) SendToDevice(dev io.ReadWriteCloser, chunks [][64]byte) (wire.Message, error) {
-	return sendToDevice(dev, chunks)
+	return sendToDevice(dev, chunks, drv.Limits())
 }
 
 // GetDevice returns a device instance
 func (drv *Driver) GetDevice() (io.ReadWriteCloser, error) {
 	var dev io.ReadWriteCloser
-	var err error
-	switch drv.DeviceType() {
-	case DeviceTypeEmulator:
-		dev, err = getEmulatorDevice()
-	case DeviceTypeUSB:
-		dev, err = getUsbDevice()
+	b, err := drv.getBus()
+	if err != nil {
+		return nil, err
 	}
+	dev, err = getBusDevice(b, drv.selection)
 
 	if dev == nil && err == nil {
 		err = errors.New("No device connected")
@@ -86,95 +88,66 @@ func (drv *Driver) GetDevice() (io.ReadWriteCloser, error) {
 	return dev, err
 }
 
-func sendToDeviceNoAnswer(dev io.ReadWriteCloser, chunks [][64]byte) error {
-	for _, element := range chunks {
-		_, err := dev.Write(element[:])
+// getBus returns the bus the devices are attached to, the bus given by WithBus if any.
+// Otherwise the emulators are reached through a usb.UDP bus, which is kept as it listens to the emulators
+// until the process exits, and the usb devices through the local WebUSB and HIDAPI buses.
+func (drv *Driver) getBus() (*usb.USB, error) {
+	if drv.bus != nil {
+		return drv.bus, nil
+	}
+
+	switch drv.DeviceType() {
+	case DeviceTypeEmulator:
+		udp, err := usb.InitUDP(drv.emulatorHost, drv.emulatorPorts)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		drv.bus = usb.Init(udp)
+		return drv.bus, nil
+	case DeviceTypeUSB:
+		return newUsbBus()
+	default:
+		return nil, fmt.Errorf("invalid device type: %s", drv.DeviceType())
 	}
-	return nil
 }
 
-func sendToDevice(dev io.ReadWriteCloser, chunks [][64]byte) (wire.Message, error) {
-	var msg wire.Message
+func sendToDeviceNoAnswer(dev io.ReadWriteCloser, chunks [][64]byte) error {
 	for _, element := range chunks {
 		_, err := dev.Write(element[:])
 		if err != nil {
-			return msg, err
+			return err
 		}
 	}
-	_, err := msg.ReadFrom(dev)
-	return msg, err
-}
-
-// getEmulatorDevice returns a emulator device connection instance
-func getEmulatorDevice() (net.Conn, error) {
-	return net.Dial("udp", "127.0.0.1:21324")
+	return nil
 }
 
-// getUsbDevice returns a usb device connection instance
-func getUsbDevice() (usb.Device, error) {
-	w, err := usb.InitWebUSB()
-	if err != nil {
-		log.Printf("webusb: %s", err)
-		return nil, err
-	}
-	h, err := usb.InitHIDAPI()
-	if err != nil {
-		log.Printf("hidapi: %s", err)
-		return nil, err
-	}
-	b := usb.Init(w, h)
-
-	var infos []usb.Info
-	infos, err = b.Enumerate()
-	if len(infos) <= 0 {
-		return nil, err
-	}
-	tries := 0
-	for tries < 3 {
-		dev, err := b.Connect(infos[0].Path)
-		if err != nil {
-			log.Print(err.Error())
-			tries++
-			time.Sleep(100 * time.Millisecond)
-		} else {
-			return dev, err
-		}
+func sendToDevice(dev io.ReadWriteCloser, chunks [][64]byte, limits wire.Limits) (wire.Message, error) {
+	if err := sendToDeviceNoAnswer(dev, chunks); err != nil {
+		return wire.Message{}, err
 	}
-	return nil, err
+	return readMessage(dev, limits)
 }
 
-func binaryWrite(message io.Writer, data interface{}) {
-	err := binary.Write(message, binary.BigEndian, data)
-	if err != nil {
-		log.Panic(err)
+// frameMessage frames a message in the reports written to the device
+func frameMessage(data []byte, kind messages.MessageType) ([][64]byte, error) {
+	var chunks reports
+	if err := wire.NewEncoder(&chunks, wire.ReportSize).Encode(wire.Message{
+		Kind: uint16(kind),
+		Data: data,
+	}); err != nil {
+		return nil, err
 	}
+	return chunks, nil
 }
 
-func makeSkyWalletMessage(data []byte, msgID messages.MessageType) [][64]byte {
-	message := new(bytes.Buffer)
-	binaryWrite(message, []byte("##"))
-	binaryWrite(message, uint16(msgID))
-	binaryWrite(message, uint32(len(data)))
-	binaryWrite(message, []byte("\n"))
-	if len(data) > 0 {
-		binaryWrite(message, data[1:])
-	}
+// reports collects the reports of a message
+type reports [][64]byte
 
-	messageLen := message.Len()
-	var chunks [][64]byte
-	i := 0
-	for messageLen > 0 {
-		var chunk [64]byte
-		chunk[0] = '?'
-		copy(chunk[1:], message.Bytes()[63*i:63*(i+1)])
-		chunks = append(chunks, chunk)
-		messageLen -= 63
-		i = i + 1
-	}
-	return chunks
+func (r *reports) Write(p []byte) (int, error) {
+	var report [64]byte
+	n := copy(report[:], p)
+	*r = append(*r, report)
+	return n, nil
 }
 
 // Initialize send an init request to the device
@@ -185,17 +158,22 @@ func Initialize(dev io.ReadWriteCloser) error {
 	if err != nil {
 		return err
 	}
-	_, err = sendToDevice(dev, chunks)
+	_, err = sendToDevice(dev, chunks, DefaultLimits())
 	return err
 }
 
 // DecodeSuccessOrFailMsg parses a success or failure msg
+// a failure msg returns its message along with the corresponding FailureError
 func DecodeSuccessOrFailMsg(msg wire.Message) (string, error) {
 	if msg.Kind == uint16(messages.MessageType_MessageType_Success) {
 		return DecodeSuccessMsg(msg)
 	}
 	if msg.Kind == uint16(messages.MessageType_MessageType_Failure) {
-		return DecodeFailMsg(msg)
+		failure, err := DecodeFailure(msg)
+		if err != nil {
+			return "", err
+		}
+		return failure.Message, failure
 	}
 
 	return "", fmt.Errorf("calling DecodeSuccessOrFailMsg on message kind %s", messages.MessageType(msg.Kind))
@@ -203,70 +181,47 @@ func DecodeSuccessOrFailMsg(msg wire.Message) (string, error) {
 
 // DecodeSuccessMsg convert byte data into string containing the success message returned by the device
 func DecodeSuccessMsg(msg wire.Message) (string, error) {
-	if msg.Kind == uint16(messages.MessageType_MessageType_Success) {
-		success := &messages.Success{}
-		err := proto.Unmarshal(msg.Data, success)
-		if err != nil {
-			return "", err
-		}
-		return success.GetMessage(), nil
+	pb, err := decodeKind(msg, messages.MessageType_MessageType_Success, "DecodeSuccessMsg")
+	if err != nil {
+		return "", err
 	}
-
-	return "", fmt.Errorf("calling DecodeSuccessMsg with wrong message type: %s", messages.MessageType(msg.Kind))
+	return pb.(*messages.Success).GetMessage(), nil
 }
 
 // DecodeFailMsg convert byte data into string containing the failure returned by the device
 func DecodeFailMsg(msg wire.Message) (string, error) {
-	if msg.Kind == uint16(messages.MessageType_MessageType_Failure) {
-		failure := &messages.Failure{}
-		err := proto.Unmarshal(msg.Data, failure)
-		if err != nil {
-			return "", err
-		}
-		return failure.GetMessage(), nil
+	pb, err := decodeKind(msg, messages.MessageType_MessageType_Failure, "DecodeFailMsg")
+	if err != nil {
+		return "", err
 	}
-	return "", fmt.Errorf("calling DecodeFailMsg with wrong message type: %s", messages.MessageType(msg.Kind))
+	return pb.(*messages.Failure).GetMessage(), nil
 }
 
 // DecodeResponseSkycoinAddress convert byte data into list of addresses, meant to be used after DevicePinMatrixAck
 func DecodeResponseSkycoinAddress(msg wire.Message) ([]string, error) {
 	log.Printf("%x\n", msg.Data)
 
-	if msg.Kind == uint16(messages.MessageType_MessageType_ResponseSkycoinAddress) {
-		responseSkycoinAddress := &messages.ResponseSkycoinAddress{}
-		err := proto.Unmarshal(msg.Data, responseSkycoinAddress)
-		if err != nil {
-			return []string{}, err
-		}
-		return responseSkycoinAddress.GetAddresses(), nil
+	pb, err := decodeKind(msg, messages.MessageType_MessageType_ResponseSkycoinAddress, "DecodeResponseSkycoinAddress")
+	if err != nil {
+		return []string{}, err
 	}
-
-	return []string{}, fmt.Errorf("calling DecodeResponseSkycoinAddress with wrong message type: %s", messages.MessageType(msg.Kind))
+	return pb.(*messages.ResponseSkycoinAddress).GetAddresses(), nil
 }
 
 // DecodeResponseTransactionSign convert byte data into list of signatures
 func DecodeResponseTransactionSign(msg wire.Message) ([]string, error) {
-	if msg.Kind == uint16(messages.MessageType_MessageType_ResponseTransactionSign) {
-		responseSkycoinTransactionSign := &messages.ResponseTransactionSign{}
-		err := proto.Unmarshal(msg.Data, responseSkycoinTransactionSign)
-		if err != nil {
-			return make([]string, 0), err
-		}
-		return responseSkycoinTransactionSign.GetSignatures(), nil
+	pb, err := decodeKind(msg, messages.MessageType_MessageType_ResponseTransactionSign, "DecodeResponseTransactionSign")
+	if err != nil {
+		return []string{}, err
 	}
-
-	return []string{}, fmt.Errorf("calling DecodeResponseeSkycoinSignMessage with wrong message type: %s", messages.MessageType(msg.Kind))
+	return pb.(*messages.ResponseTransactionSign).GetSignatures(), nil
 }
 
 // DecodeResponseSkycoinSignMessage convert byte data into signed message, meant to be used after DevicePinMatrixAck
 func DecodeResponseSkycoinSignMessage(msg wire.Message) (string, error) {
-	if msg.Kind == uint16(messages.MessageType_MessageType_ResponseSkycoinSignMessage) {
-		responseSkycoinSignMessage := &messages.ResponseSkycoinSignMessage{}
-		err := proto.Unmarshal(msg.Data, responseSkycoinSignMessage)
-		if err != nil {
-			return "", err
-		}
-		return responseSkycoinSignMessage.GetSignedMessage(), nil
+	pb, err := decodeKind(msg, messages.MessageType_MessageType_ResponseSkycoinSignMessage, "DecodeResponseSkycoinSignMessage")
+	if err != nil {
+		return "", err
 	}
-	return "", fmt.Errorf("calling DecodeResponseeSkycoinSignMessage with wrong message type: %s", messages.MessageType(msg.Kind))
+	return pb.(*messages.ResponseSkycoinSignMessage).GetSignedMessage(), nil
 }
@@ -1,8 +1,10 @@
 package integration
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"testing"
 
 	"github.com/gogo/protobuf/proto"
@@ -10,6 +12,7 @@ import (
 
 	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
 	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/simulator"
 	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
 )
 
@@ -21,8 +24,8 @@ func testHelperGetDeviceWithBestEffort(testName string, t *testing.T) *deviceWal
 	} else if emDevice.Connected() {
 		return emDevice
 	}
-	t.Skip(testName + " does not work if neither Emulator nor USB device is connected")
-	return nil
+	t.Logf("%s: neither Emulator nor USB device is connected, using the simulator", testName)
+	return simulator.NewDevice(simulator.New())
 }
 
 func TestDevice(t *testing.T) {
@@ -164,13 +167,107 @@ func TestGetAddressEmulator(t *testing.T) {
 	require.Equal(t, addresses[1], "zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs")
 }
 
-func TransactionToDevice(deviceType deviceWallet.DeviceType, transactionInputs []*messages.SkycoinTransactionInput, transactionOutputs []*messages.SkycoinTransactionOutput) (wire.Message, error) {
-	device := deviceWallet.NewDevice(deviceType)
-	if device == nil {
-		return wire.Message{}, fmt.Errorf("invalid device type: %s", deviceType)
+// testHelperDebugInteractor sets on device an Interactor answering through its DebugLink,
+// the returned function restores the device
+func testHelperDebugInteractor(device *deviceWallet.Device) (*deviceWallet.DebugInteractor, func(), error) {
+	link, err := device.DebugLink()
+	if err != nil {
+		return nil, nil, err
+	}
+	// the buttons are pressed through the DebugLink
+	if err := device.SetAutoPressButton(false, deviceWallet.ButtonRight); err != nil {
+		link.Close()
+		return nil, nil, err
 	}
+	interactor := &deviceWallet.DebugInteractor{Link: link}
+	device.SetInteractor(interactor)
+	return interactor, func() {
+		device.SetInteractor(nil)
+		link.Close()
+	}, nil
+}
 
-	if device.Driver.DeviceType() == deviceWallet.DeviceTypeEmulator {
+// testHelperGetDebugDevice returns a wiped device answered through its DebugLink, the test is skipped for a USB device.
+// The returned function restores the device.
+func testHelperGetDebugDevice(testName string, t *testing.T) (*deviceWallet.Device, *deviceWallet.DebugInteractor, func()) {
+	device := testHelperGetDeviceWithBestEffort(testName, t)
+	interactor, restore, err := testHelperDebugInteractor(device)
+	if err == deviceWallet.ErrNoDebugLink {
+		t.Skipf("%s needs the DebugLink of the emulator", testName)
+	}
+	require.NoError(t, err)
+
+	_, err = device.Wipe()
+	require.NoError(t, err)
+	return device, interactor, restore
+}
+
+func TestChangePin(t *testing.T) {
+	device, interactor, restore := testHelperGetDebugDevice("TestChangePin", t)
+	defer restore()
+
+	_, err := device.SetMnemonic("cloud flower upset remain green metal below cup stem infant art thank")
+	require.NoError(t, err)
+
+	interactor.Pin = "1234"
+	msg, err := device.ChangePin()
+	require.NoError(t, err)
+	require.Equal(t, uint16(messages.MessageType_MessageType_Success), msg.Kind)
+
+	state, err := interactor.Link.State()
+	require.NoError(t, err)
+	require.Equal(t, "1234", state.GetPin())
+
+	msg, err = device.AddressGen(1, 0, false)
+	require.NoError(t, err)
+	addresses, err := deviceWallet.DecodeResponseSkycoinAddress(msg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw"}, addresses)
+}
+
+func TestRecovery(t *testing.T) {
+	device, interactor, restore := testHelperGetDebugDevice("TestRecovery", t)
+	defer restore()
+
+	interactor.Mnemonic = "cloud flower upset remain green metal below cup stem infant art thank"
+	msg, err := device.Recovery(12, false, false)
+	require.NoError(t, err)
+	require.Equal(t, uint16(messages.MessageType_MessageType_Success), msg.Kind)
+
+	state, err := interactor.Link.State()
+	require.NoError(t, err)
+	require.Equal(t, interactor.Mnemonic, state.GetMnemonic())
+
+	msg, err = device.AddressGen(1, 0, false)
+	require.NoError(t, err)
+	addresses, err := deviceWallet.DecodeResponseSkycoinAddress(msg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw"}, addresses)
+}
+
+func TestBackup(t *testing.T) {
+	device, interactor, restore := testHelperGetDebugDevice("TestBackup", t)
+	defer restore()
+
+	msg, err := device.GenerateMnemonic(12, false)
+	require.NoError(t, err)
+	require.Equal(t, uint16(messages.MessageType_MessageType_Success), msg.Kind)
+
+	msg, err = device.Backup()
+	require.NoError(t, err)
+	require.Equal(t, uint16(messages.MessageType_MessageType_Success), msg.Kind)
+
+	// the words displayed are the seed of the device
+	state, err := interactor.Link.State()
+	require.NoError(t, err)
+	require.Equal(t, state.GetMnemonic(), strings.Join(interactor.Words, " "))
+}
+
+func TransactionToDevice(device *deviceWallet.Device, transactionInputs []*messages.SkycoinTransactionInput, transactionOutputs []*messages.SkycoinTransactionOutput) (wire.Message, error) {
+	// the DebugLink answers the PIN and passphrase requests and presses the buttons
+	if _, restore, err := testHelperDebugInteractor(device); err == nil {
+		defer restore()
+	} else if device.Driver.DeviceType() == deviceWallet.DeviceTypeEmulator {
 		err := device.SetAutoPressButton(true, deviceWallet.ButtonRight)
 		if err != nil {
 			return wire.Message{}, err
@@ -186,35 +283,20 @@ func TransactionToDevice(deviceType deviceWallet.DeviceType, transactionInputs [
 		case uint16(messages.MessageType_MessageType_ResponseTransactionSign):
 			return msg, nil
 		case uint16(messages.MessageType_MessageType_Success):
-			fmt.Println("Should end with ResponseTransactionSign request")
+			return wire.Message{}, errors.New("should end with ResponseTransactionSign request")
 		case uint16(messages.MessageType_MessageType_ButtonRequest):
 			msg, err = device.ButtonAck()
 			if err != nil {
 				return wire.Message{}, err
 			}
-		case uint16(messages.MessageType_MessageType_PassphraseRequest):
-			var passphrase string
-			fmt.Printf("Input passphrase: ")
-			fmt.Scanln(&passphrase)
-			msg, err = device.PassphraseAck(passphrase)
-			if err != nil {
-				return wire.Message{}, err
-			}
-		case uint16(messages.MessageType_MessageType_PinMatrixRequest):
-			var pinEnc string
-			fmt.Printf("PinMatrixRequest response: ")
-			fmt.Scanln(&pinEnc)
-			msg, err = device.PinMatrixAck(pinEnc)
-			if err != nil {
-				return wire.Message{}, err
-			}
 		case uint16(messages.MessageType_MessageType_Failure):
 			failMsg, err := deviceWallet.DecodeFailMsg(msg)
 			if err != nil {
 				return wire.Message{}, err
 			}
-			fmt.Printf("Failed with message: %s\n", failMsg)
+			return wire.Message{}, fmt.Errorf("failed with message: %s", failMsg)
 		default:
+			// PinMatrixRequest and PassphraseRequest are only answered through the DebugLink
 			return wire.Message{}, fmt.Errorf("received unexpected message type: %s", messages.MessageType(msg.Kind))
 		}
 	}
@@ -256,7 +338,7 @@ func TestTransactions(t *testing.T) {
 	transactionOutput.Hour = proto.Uint64(2)
 	transactionOutputs = append(transactionOutputs, &transactionOutput)
 
-	msg, err := TransactionToDevice(device.Driver.DeviceType(), transactionInputs, transactionOutputs)
+	msg, err := TransactionToDevice(device, transactionInputs, transactionOutputs)
 	require.NoError(t, err)
 	require.Equal(t, uint16(messages.MessageType_MessageType_ResponseTransactionSign), msg.Kind)
 
@@ -288,7 +370,7 @@ func TestTransactions(t *testing.T) {
 	transactionOutput.Hour = proto.Uint64(255)
 	transactionOutputs = append(transactionOutputs, &transactionOutput)
 
-	msg, err = TransactionToDevice(device.Driver.DeviceType(), transactionInputs, transactionOutputs)
+	msg, err = TransactionToDevice(device, transactionInputs, transactionOutputs)
 	require.NoError(t, err)
 	require.Equal(t, msg.Kind, uint16(messages.MessageType_MessageType_ResponseTransactionSign))
 
@@ -334,7 +416,7 @@ func TestTransactions(t *testing.T) {
 	transactionOutput1.Hour = proto.Uint64(1)
 	transactionOutputs = append(transactionOutputs, &transactionOutput1)
 
-	msg, err = TransactionToDevice(device.Driver.DeviceType(), transactionInputs, transactionOutputs)
+	msg, err = TransactionToDevice(device, transactionInputs, transactionOutputs)
 	require.NoError(t, err)
 	require.Equal(t, msg.Kind, uint16(messages.MessageType_MessageType_ResponseTransactionSign))
 
@@ -379,7 +461,7 @@ func TestTransactions(t *testing.T) {
 	transactionOutput.Hour = proto.Uint64(0)
 	transactionOutputs = append(transactionOutputs, &transactionOutput)
 
-	msg, err = TransactionToDevice(device.Driver.DeviceType(), transactionInputs, transactionOutputs)
+	msg, err = TransactionToDevice(device, transactionInputs, transactionOutputs)
 	require.NoError(t, err)
 	require.Equal(t, msg.Kind, uint16(messages.MessageType_MessageType_ResponseTransactionSign))
 
@@ -414,7 +496,7 @@ func TestTransactions(t *testing.T) {
 	transactionOutput.Hour = proto.Uint64(0)
 	transactionOutputs = append(transactionOutputs, &transactionOutput)
 
-	msg, err = TransactionToDevice(device.Driver.DeviceType(), transactionInputs, transactionOutputs)
+	msg, err = TransactionToDevice(device, transactionInputs, transactionOutputs)
 	require.NoError(t, err)
 	require.Equal(t, msg.Kind, uint16(messages.MessageType_MessageType_ResponseTransactionSign))
 
@@ -450,7 +532,7 @@ func TestTransactions(t *testing.T) {
 	transactionOutput2.Hour = proto.Uint64(1)
 	transactionOutputs = append(transactionOutputs, &transactionOutput2)
 
-	msg, err = TransactionToDevice(device.Driver.DeviceType(), transactionInputs, transactionOutputs)
+	msg, err = TransactionToDevice(device, transactionInputs, transactionOutputs)
 	require.NoError(t, err)
 	require.Equal(t, msg.Kind, uint16(messages.MessageType_MessageType_ResponseTransactionSign))
 
@@ -485,7 +567,7 @@ func TestTransactions(t *testing.T) {
 	transactionOutput.Hour = proto.Uint64(33)
 	transactionOutputs = append(transactionOutputs, &transactionOutput)
 
-	msg, err = TransactionToDevice(device.Driver.DeviceType(), transactionInputs, transactionOutputs)
+	msg, err = TransactionToDevice(device, transactionInputs, transactionOutputs)
 	require.NoError(t, err)
 	require.Equal(t, msg.Kind, uint16(messages.MessageType_MessageType_ResponseTransactionSign))
 
@@ -528,7 +610,7 @@ func TestTransactions(t *testing.T) {
 	transactionOutput.Hour = proto.Uint64(1000)
 	transactionOutputs = append(transactionOutputs, &transactionOutput)
 
-	msg, err = TransactionToDevice(device.Driver.DeviceType(), transactionInputs, transactionOutputs)
+	msg, err = TransactionToDevice(device, transactionInputs, transactionOutputs)
 	require.NoError(t, err)
 	require.Equal(t, msg.Kind, uint16(messages.MessageType_MessageType_ResponseTransactionSign))
 
@@ -561,7 +643,7 @@ func TestTransactions(t *testing.T) {
 	transactionOutput1.Hour = proto.Uint64(500)
 	transactionOutputs = append(transactionOutputs, &transactionOutput1)
 
-	msg, err = TransactionToDevice(device.Driver.DeviceType(), transactionInputs, transactionOutputs)
+	msg, err = TransactionToDevice(device, transactionInputs, transactionOutputs)
 	require.NoError(t, err)
 	require.Equal(t, msg.Kind, uint16(messages.MessageType_MessageType_ResponseTransactionSign))
 
@@ -590,7 +672,7 @@ func TestTransactions(t *testing.T) {
 	transactionOutput.Hour = proto.Uint64(1000)
 	transactionOutputs = append(transactionOutputs, &transactionOutput)
 
-	msg, err = TransactionToDevice(device.Driver.DeviceType(), transactionInputs, transactionOutputs)
+	msg, err = TransactionToDevice(device, transactionInputs, transactionOutputs)
 	require.NoError(t, err)
 	require.Equal(t, msg.Kind, uint16(messages.MessageType_MessageType_ResponseTransactionSign))
 
@@ -0,0 +1,133 @@
+package devicewallet
+
+import (
+	"github.com/gogo/protobuf/proto"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+// Interactor answers the device requests that need the user, it is used by Device
+// to drive an operation until the device sends its final answer.
+// When any method returns an error the ongoing operation is cancelled on the device
+// and the error is returned to the caller. When the context of an operation run by a *Context
+// method is done, the operation does not wait for the Interactor: the method keeps running
+// and its answer is discarded.
+type Interactor interface {
+	// RequestPin returns the PIN encoded as positions on the matrix displayed by the device, see Device.ChangePin
+	RequestPin(pinType messages.PinMatrixRequestType) (string, error)
+	// RequestPassphrase returns the wallet passphrase
+	RequestPassphrase() (string, error)
+	// RequestWord returns the next word of the seed during the recovery procedure
+	RequestWord() (string, error)
+	// OnButton is called when the device waits for the user to press a button
+	OnButton(code messages.ButtonRequestType) error
+}
+
+// SetInteractor sets the Interactor answering the device requests.
+// Without Interactor PinMatrixRequest, PassphraseRequest and WordRequest messages
+// are returned to the caller, which has to answer them with the corresponding Ack method.
+func (d *Device) SetInteractor(interactor Interactor) {
+	d.interactor = interactor
+}
+
+// interact answers the device requests until it sends a message not needing any interaction
+func (d *Device) interact(msg wire.Message) (wire.Message, error) {
+	// the Interactor may be left answering a request after the operation returned, see ask
+	interactor := d.interactor
+	var err error
+	for {
+		switch msg.Kind {
+		case uint16(messages.MessageType_MessageType_ButtonRequest):
+			if interactor != nil {
+				buttonRequest := &messages.ButtonRequest{}
+				if err = proto.Unmarshal(msg.Data, buttonRequest); err != nil {
+					return wire.Message{}, err
+				}
+				if _, err = d.ask(func() (string, error) {
+					return "", interactor.OnButton(buttonRequest.GetCode())
+				}); err != nil {
+					return d.abort(err)
+				}
+			}
+			msg, err = d.ButtonAck()
+		case uint16(messages.MessageType_MessageType_PinMatrixRequest):
+			if interactor == nil {
+				return msg, nil
+			}
+			pinMatrixRequest := &messages.PinMatrixRequest{}
+			if err = proto.Unmarshal(msg.Data, pinMatrixRequest); err != nil {
+				return wire.Message{}, err
+			}
+			var pin string
+			if pin, err = d.ask(func() (string, error) {
+				return interactor.RequestPin(pinMatrixRequest.GetType())
+			}); err != nil {
+				return d.abort(err)
+			}
+			msg, err = d.PinMatrixAck(pin)
+		case uint16(messages.MessageType_MessageType_PassphraseRequest):
+			if interactor == nil {
+				return msg, nil
+			}
+			var passphrase string
+			if passphrase, err = d.ask(interactor.RequestPassphrase); err != nil {
+				return d.abort(err)
+			}
+			msg, err = d.PassphraseAck(passphrase)
+		case uint16(messages.MessageType_MessageType_WordRequest):
+			if interactor == nil {
+				return msg, nil
+			}
+			var word string
+			if word, err = d.ask(interactor.RequestWord); err != nil {
+				return d.abort(err)
+			}
+			msg, err = d.WordAck(word)
+		default:
+			return msg, nil
+		}
+
+		if err != nil {
+			return wire.Message{}, err
+		}
+	}
+}
+
+// ask returns the answer of the Interactor to request, or the error of the context of the operation
+// once it is done, without waiting for the Interactor
+func (d *Device) ask(request func() (string, error)) (string, error) {
+	ctx := d.operationContext()
+	if ctx == nil {
+		return request()
+	}
+
+	type answer struct {
+		s   string
+		err error
+	}
+	done := make(chan answer, 1)
+	go func() {
+		s, err := request()
+		done <- answer{s, err}
+	}()
+
+	select {
+	case a := <-done:
+		return a.s, a.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// abort cancels the ongoing operation after the Interactor failed.
+// The operation whose context is done was cancelled by withContext already.
+func (d *Device) abort(err error) (wire.Message, error) {
+	if d.contextErr() != nil {
+		return wire.Message{}, err
+	}
+	if _, cancelErr := d.Cancel(); cancelErr != nil {
+		log.Errorf("cancelling the operation: %v", cancelErr)
+	}
+	return wire.Message{}, err
+}
@@ -0,0 +1,60 @@
+package devicewallet
+
+import (
+	"io"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+// DefaultMaxMessageSize is the maximum payload size of the messages sent by the device
+// whose kind has no limit in DefaultLimits
+const DefaultMaxMessageSize = 64 * 1024
+
+// DefaultLimits returns the maximum payload sizes of the messages sent by the device, per message type.
+// A larger message is refused before its payload is read, with a wire.ValidationError.
+func DefaultLimits() wire.Limits {
+	return wire.Limits{
+		Default: DefaultMaxMessageSize,
+		Kinds: map[uint16]int{
+			uint16(messages.MessageType_MessageType_Success):                    8 * 1024,
+			uint16(messages.MessageType_MessageType_Failure):                    1024,
+			uint16(messages.MessageType_MessageType_Features):                   2048,
+			uint16(messages.MessageType_MessageType_ButtonRequest):              256,
+			uint16(messages.MessageType_MessageType_PinMatrixRequest):           256,
+			uint16(messages.MessageType_MessageType_PassphraseRequest):          256,
+			uint16(messages.MessageType_MessageType_WordRequest):                256,
+			uint16(messages.MessageType_MessageType_EntropyRequest):             256,
+			uint16(messages.MessageType_MessageType_ResponseSkycoinAddress):     16 * 1024,
+			uint16(messages.MessageType_MessageType_ResponseSkycoinSignMessage): 1024,
+			uint16(messages.MessageType_MessageType_ResponseTransactionSign):    16 * 1024,
+		},
+	}
+}
+
+// Limits returns the maximum payload sizes of the messages read from the device, see WithLimits
+func (drv *Driver) Limits() wire.Limits {
+	if drv.limits == nil {
+		return DefaultLimits()
+	}
+	return *drv.limits
+}
+
+// limits returns the limits of the driver of d, DefaultLimits when its driver has none
+func (d *Device) limits() wire.Limits {
+	if l, ok := d.Driver.(interface{ Limits() wire.Limits }); ok {
+		return l.Limits()
+	}
+	return DefaultLimits()
+}
+
+// readMessage reads a message sent by the device, the message is checked against limits and validated
+// before it is returned
+func readMessage(dev io.Reader, limits wire.Limits) (wire.Message, error) {
+	var msg wire.Message
+	decoder := wire.NewDecoder(dev, wire.ReportSize)
+	decoder.SetLimits(limits)
+	decoder.SetValidate(true)
+	err := decoder.Decode(&msg)
+	return msg, err
+}
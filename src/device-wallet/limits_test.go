@@ -0,0 +1,89 @@
+package devicewallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+// testHelperAnswerDevice discards the reports written and answers with its reports
+type testHelperAnswerDevice struct {
+	reports [][]byte
+}
+
+func (dev *testHelperAnswerDevice) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (dev *testHelperAnswerDevice) Read(p []byte) (int, error) {
+	if len(dev.reports) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, dev.reports[0])
+	dev.reports = dev.reports[1:]
+	return n, nil
+}
+
+func (dev *testHelperAnswerDevice) Close() error {
+	return nil
+}
+
+func (dev *testHelperAnswerDevice) answer(t *testing.T, msg wire.Message) {
+	var buf bytes.Buffer
+	_, err := msg.WriteTo(&buf)
+	require.NoError(t, err)
+	for buf.Len() > 0 {
+		dev.reports = append(dev.reports, buf.Next(wire.ReportSize))
+	}
+}
+
+func TestLimits(t *testing.T) {
+	chunks, err := MessageGetFeatures()
+	require.NoError(t, err)
+	success := uint16(messages.MessageType_MessageType_Success)
+
+	// the size announced is refused before the payload is read
+	dev := &testHelperAnswerDevice{}
+	dev.answer(t, wire.Message{Kind: uint16(messages.MessageType_MessageType_Features)})
+	binary.BigEndian.PutUint32(dev.reports[0][5:], 0xffffffff)
+	_, err = newDriver(DeviceTypeUSB).SendToDevice(dev, chunks)
+	require.Equal(t, wire.ValidationError{
+		Kind:  uint16(messages.MessageType_MessageType_Features),
+		Size:  0xffffffff,
+		Limit: 2048,
+		Err:   wire.ErrMessageTooLarge,
+	}, err)
+
+	large := testHelperMessage(t, messages.MessageType_MessageType_Success, &messages.Success{
+		Message: proto.String(string(bytes.Repeat([]byte("a"), 10*1024))),
+	})
+	dev = &testHelperAnswerDevice{}
+	dev.answer(t, large)
+	_, err = newDriver(DeviceTypeUSB).SendToDevice(dev, chunks)
+	require.True(t, errors.Is(err, wire.ErrMessageTooLarge))
+
+	dev = &testHelperAnswerDevice{}
+	dev.answer(t, large)
+	msg, err := newDriver(DeviceTypeUSB, WithLimits(wire.Limits{
+		Kinds: map[uint16]int{success: 16 * 1024},
+	})).SendToDevice(dev, chunks)
+	require.NoError(t, err)
+	require.Equal(t, large, msg)
+
+	// the payload is validated
+	dev = &testHelperAnswerDevice{}
+	dev.answer(t, wire.Message{Kind: success, Data: []byte{0x0a, 0x10, 'a'}})
+	_, err = newDriver(DeviceTypeUSB).SendToDevice(dev, chunks)
+	var validationErr wire.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Equal(t, success, validationErr.Kind)
+	require.Equal(t, wire.ErrMalformedProtobuf, validationErr.Err)
+}
@@ -13,23 +13,13 @@ import (
 // MessageCancel prepare Cancel request
 func MessageCancel() ([][64]byte, error) {
 	msg := &messages.Cancel{}
-	data, err := proto.Marshal(msg)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_Cancel)
-	return chunks, nil
+	return EncodeMessage(msg)
 }
 
 // MessageButtonAck send this message (before user action) when the device expects the user to push a button
 func MessageButtonAck() ([][64]byte, error) {
 	buttonAck := &messages.ButtonAck{}
-	data, err := proto.Marshal(buttonAck)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_ButtonAck)
-	return chunks, nil
+	return EncodeMessage(buttonAck)
 }
 
 // MessagePassphraseAck send this message when the device expects receiving a Passphrase
@@ -37,12 +27,7 @@ func MessagePassphraseAck(passphrase string) ([][64]byte, error) {
 	msg := &messages.PassphraseAck{
 		Passphrase: proto.String(passphrase),
 	}
-	data, err := proto.Marshal(msg)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_PassphraseAck)
-	return chunks, nil
+	return EncodeMessage(msg)
 }
 
 // MessageWordAck send this message between each word of the seed (before user action) during device backup
@@ -50,12 +35,7 @@ func MessageWordAck(word string) ([][64]byte, error) {
 	wordAck := &messages.WordAck{
 		Word: proto.String(word),
 	}
-	data, err := proto.Marshal(wordAck)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_WordAck)
-	return chunks, nil
+	return EncodeMessage(wordAck)
 }
 
 // MessageCheckMessageSignature prepare CheckMessageSignature request
@@ -66,12 +46,7 @@ func MessageCheckMessageSignature(message, signature, address string) ([][64]byt
 		Signature: proto.String(signature),
 	}
 
-	data, err := proto.Marshal(msg)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_SkycoinCheckMessageSignature)
-	return chunks, nil
+	return EncodeMessage(msg)
 }
 
 // MessageAddressGen prepare MessageAddressGen request
@@ -82,13 +57,7 @@ func MessageAddressGen(addressN, startIndex int, confirmAddress bool) ([][64]byt
 		StartIndex:     proto.Uint32(uint32(startIndex)),
 	}
 
-	data, err := proto.Marshal(skycoinAddress)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_SkycoinAddress)
-	return chunks, nil
+	return EncodeMessage(skycoinAddress)
 }
 
 // MessageApplySettings prepare MessageApplySettings request
@@ -99,46 +68,25 @@ func MessageApplySettings(usePassphrase bool, label string) ([][64]byte, error)
 		UsePassphrase: proto.Bool(usePassphrase),
 	}
 	log.Println(applySettings)
-	data, err := proto.Marshal(applySettings)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_ApplySettings)
-	return chunks, nil
+	return EncodeMessage(applySettings)
 }
 
 // MessageBackup prepare MessageBackup request
 func MessageBackup() ([][64]byte, error) {
 	backupDevice := &messages.BackupDevice{}
-	data, err := proto.Marshal(backupDevice)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_BackupDevice)
-	return chunks, nil
+	return EncodeMessage(backupDevice)
 }
 
 // MessageChangePin prepare MessageChangePin request
 func MessageChangePin() ([][64]byte, error) {
 	changePin := &messages.ChangePin{}
-	data, err := proto.Marshal(changePin)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_ChangePin)
-	return chunks, nil
+	return EncodeMessage(changePin)
 }
 
 // MessageConnected prepare MessageConnected request
 func MessageConnected() ([][64]byte, error) {
 	msgRaw := &messages.Ping{}
-	data, err := proto.Marshal(msgRaw)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_Ping)
-	return chunks, nil
+	return EncodeMessage(msgRaw)
 }
 
 // MessageFirmwareErase prepare MessageFirmwareErase request
@@ -147,13 +95,7 @@ func MessageFirmwareErase(payload []byte) ([][64]byte, error) {
 		Length: proto.Uint32(uint32(len(payload))),
 	}
 
-	erasedata, err := proto.Marshal(deviceFirmwareErase)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(erasedata, messages.MessageType_MessageType_FirmwareErase)
-	return chunks, nil
+	return EncodeMessage(deviceFirmwareErase)
 }
 
 // MessageFirmwareUpload prepare MessageFirmwareUpload request
@@ -163,25 +105,13 @@ func MessageFirmwareUpload(payload []byte, hash [32]byte) ([][64]byte, error) {
 		Hash:    hash[:],
 	}
 
-	uploaddata, err := proto.Marshal(deviceFirmwareUpload)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(uploaddata, messages.MessageType_MessageType_FirmwareUpload)
-	return chunks, nil
+	return EncodeMessage(deviceFirmwareUpload)
 }
 
 // MessageGetFeatures prepare MessageGetFeatures request
 func MessageGetFeatures() ([][64]byte, error) {
 	featureMsg := &messages.GetFeatures{}
-	data, err := proto.Marshal(featureMsg)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_GetFeatures)
-	return chunks, nil
+	return EncodeMessage(featureMsg)
 }
 
 // MessageGenerateMnemonic prepare MessageGenerateMnemonic request
@@ -191,13 +121,7 @@ func MessageGenerateMnemonic(wordCount uint32, usePassphrase bool) ([][64]byte,
 		WordCount:            proto.Uint32(wordCount),
 	}
 
-	data, err := proto.Marshal(skycoinGenerateMnemonic)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_GenerateMnemonic)
-	return chunks, nil
+	return EncodeMessage(skycoinGenerateMnemonic)
 }
 
 // MessageRecovery prepare MessageRecovery request
@@ -207,14 +131,7 @@ func MessageRecovery(wordCount uint32, usePassphrase, dryRun bool) ([][64]byte,
 		PassphraseProtection: proto.Bool(usePassphrase),
 		DryRun:               proto.Bool(dryRun),
 	}
-	data, err := proto.Marshal(recoveryDevice)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_RecoveryDevice)
-
-	return chunks, nil
+	return EncodeMessage(recoveryDevice)
 }
 
 // MessageSetMnemonic prepare MessageSetMnemonic request
@@ -223,13 +140,7 @@ func MessageSetMnemonic(mnemonic string) ([][64]byte, error) {
 		Mnemonic: proto.String(mnemonic),
 	}
 
-	data, err := proto.Marshal(skycoinSetMnemonic)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_SetMnemonic)
-	return chunks, nil
+	return EncodeMessage(skycoinSetMnemonic)
 }
 
 // MessageSignMessage prepare MessageSignMessage request
@@ -239,13 +150,7 @@ func MessageSignMessage(addressIndex int, message string) ([][64]byte, error) {
 		Message:  proto.String(message),
 	}
 
-	data, err := proto.Marshal(skycoinSignMessage)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_SkycoinSignMessage)
-	return chunks, nil
+	return EncodeMessage(skycoinSignMessage)
 }
 
 // MessageTransactionSign prepare MessageTransactionSign request
@@ -258,25 +163,13 @@ func MessageTransactionSign(inputs []*messages.SkycoinTransactionInput, outputs
 	}
 	log.Println(skycoinTransactionSignMessage)
 
-	data, err := proto.Marshal(skycoinTransactionSignMessage)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_TransactionSign)
-	return chunks, nil
+	return EncodeMessage(skycoinTransactionSignMessage)
 }
 
 // MessageWipe prepare MessageWipe request
 func MessageWipe() ([][64]byte, error) {
 	wipeDevice := &messages.WipeDevice{}
-	data, err := proto.Marshal(wipeDevice)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_WipeDevice)
-	return chunks, nil
+	return EncodeMessage(wipeDevice)
 }
 
 // MessagePinMatrixAck prepare MessagePinMatrixAck request
@@ -284,13 +177,7 @@ func MessagePinMatrixAck(p string) ([][64]byte, error) {
 	pinAck := &messages.PinMatrixAck{
 		Pin: proto.String(p),
 	}
-	data, err := proto.Marshal(pinAck)
-	if err != nil {
-		return [][64]byte{}, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_PinMatrixAck)
-	return chunks, nil
+	return EncodeMessage(pinAck)
 }
 
 // MessageEntropyAck prepare MessageEntropyAck request
@@ -302,24 +189,13 @@ func MessageEntropyAck(bufferSize int) ([][64]byte, error) {
 	entropyAck := &messages.EntropyAck{
 		Entropy: buffer,
 	}
-	data, err := proto.Marshal(entropyAck)
-	if err != nil {
-		return nil, err
-	}
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_EntropyAck)
-	return chunks, nil
+	return EncodeMessage(entropyAck)
 }
 
 // MessageInitialize prepare MessageInitialize request
 func MessageInitialize() ([][64]byte, error) {
 	initialize := &messages.Initialize{}
-	data, err := proto.Marshal(initialize)
-	if err != nil {
-		return nil, err
-	}
-
-	chunks := makeSkyWalletMessage(data, messages.MessageType_MessageType_Initialize)
-	return chunks, nil
+	return EncodeMessage(initialize)
 }
 
 // MessageSimulateButtonPress prespares a emulator button press simulation button
@@ -2,8 +2,10 @@
 
 package devicewallet
 
+import context "context"
 import messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
 import mock "github.com/stretchr/testify/mock"
+import proto "github.com/gogo/protobuf/proto"
 import wire "github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
 
 // MockDevicer is an autogenerated mock type for the Devicer type
@@ -32,6 +34,27 @@ func (_m *MockDevicer) AddressGen(addressN int, startIndex int, confirmAddress b
 	return r0, r1
 }
 
+// AddressGenContext provides a mock function with given fields: ctx, addressN, startIndex, confirmAddress
+func (_m *MockDevicer) AddressGenContext(ctx context.Context, addressN int, startIndex int, confirmAddress bool) (wire.Message, error) {
+	ret := _m.Called(ctx, addressN, startIndex, confirmAddress)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, bool) wire.Message); ok {
+		r0 = rf(ctx, addressN, startIndex, confirmAddress)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, bool) error); ok {
+		r1 = rf(ctx, addressN, startIndex, confirmAddress)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ApplySettings provides a mock function with given fields: usePassphrase, label
 func (_m *MockDevicer) ApplySettings(usePassphrase bool, label string) (wire.Message, error) {
 	ret := _m.Called(usePassphrase, label)
@@ -53,6 +76,27 @@ func (_m *MockDevicer) ApplySettings(usePassphrase bool, label string) (wire.Mes
 	return r0, r1
 }
 
+// ApplySettingsContext provides a mock function with given fields: ctx, usePassphrase, label
+func (_m *MockDevicer) ApplySettingsContext(ctx context.Context, usePassphrase bool, label string) (wire.Message, error) {
+	ret := _m.Called(ctx, usePassphrase, label)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context, bool, string) wire.Message); ok {
+		r0 = rf(ctx, usePassphrase, label)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, bool, string) error); ok {
+		r1 = rf(ctx, usePassphrase, label)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Backup provides a mock function with given fields:
 func (_m *MockDevicer) Backup() (wire.Message, error) {
 	ret := _m.Called()
@@ -74,6 +118,27 @@ func (_m *MockDevicer) Backup() (wire.Message, error) {
 	return r0, r1
 }
 
+// BackupContext provides a mock function with given fields: ctx
+func (_m *MockDevicer) BackupContext(ctx context.Context) (wire.Message, error) {
+	ret := _m.Called(ctx)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context) wire.Message); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ButtonAck provides a mock function with given fields:
 func (_m *MockDevicer) ButtonAck() (wire.Message, error) {
 	ret := _m.Called()
@@ -95,6 +160,73 @@ func (_m *MockDevicer) ButtonAck() (wire.Message, error) {
 	return r0, r1
 }
 
+// ButtonAckContext provides a mock function with given fields: ctx
+func (_m *MockDevicer) ButtonAckContext(ctx context.Context) (wire.Message, error) {
+	ret := _m.Called(ctx)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context) wire.Message); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Call provides a mock function with given fields: req
+func (_m *MockDevicer) Call(req proto.Message) (proto.Message, error) {
+	ret := _m.Called(req)
+
+	var r0 proto.Message
+	if rf, ok := ret.Get(0).(func(proto.Message) proto.Message); ok {
+		r0 = rf(req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(proto.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(proto.Message) error); ok {
+		r1 = rf(req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CallContext provides a mock function with given fields: ctx, req
+func (_m *MockDevicer) CallContext(ctx context.Context, req proto.Message) (proto.Message, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 proto.Message
+	if rf, ok := ret.Get(0).(func(context.Context, proto.Message) proto.Message); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(proto.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, proto.Message) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Cancel provides a mock function with given fields:
 func (_m *MockDevicer) Cancel() (wire.Message, error) {
 	ret := _m.Called()
@@ -116,6 +248,27 @@ func (_m *MockDevicer) Cancel() (wire.Message, error) {
 	return r0, r1
 }
 
+// CancelContext provides a mock function with given fields: ctx
+func (_m *MockDevicer) CancelContext(ctx context.Context) (wire.Message, error) {
+	ret := _m.Called(ctx)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context) wire.Message); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ChangePin provides a mock function with given fields:
 func (_m *MockDevicer) ChangePin() (wire.Message, error) {
 	ret := _m.Called()
@@ -137,6 +290,27 @@ func (_m *MockDevicer) ChangePin() (wire.Message, error) {
 	return r0, r1
 }
 
+// ChangePinContext provides a mock function with given fields: ctx
+func (_m *MockDevicer) ChangePinContext(ctx context.Context) (wire.Message, error) {
+	ret := _m.Called(ctx)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context) wire.Message); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CheckMessageSignature provides a mock function with given fields: message, signature, address
 func (_m *MockDevicer) CheckMessageSignature(message string, signature string, address string) (wire.Message, error) {
 	ret := _m.Called(message, signature, address)
@@ -158,6 +332,41 @@ func (_m *MockDevicer) CheckMessageSignature(message string, signature string, a
 	return r0, r1
 }
 
+// CheckMessageSignatureContext provides a mock function with given fields: ctx, message, signature, address
+func (_m *MockDevicer) CheckMessageSignatureContext(ctx context.Context, message string, signature string, address string) (wire.Message, error) {
+	ret := _m.Called(ctx, message, signature, address)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) wire.Message); ok {
+		r0 = rf(ctx, message, signature, address)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, message, signature, address)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CloseSession provides a mock function with given fields:
+func (_m *MockDevicer) CloseSession() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Connected provides a mock function with given fields:
 func (_m *MockDevicer) Connected() bool {
 	ret := _m.Called()
@@ -172,6 +381,20 @@ func (_m *MockDevicer) Connected() bool {
 	return r0
 }
 
+// ConnectedContext provides a mock function with given fields: ctx
+func (_m *MockDevicer) ConnectedContext(ctx context.Context) bool {
+	ret := _m.Called(ctx)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context) bool); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // FirmwareUpload provides a mock function with given fields: payload, hash
 func (_m *MockDevicer) FirmwareUpload(payload []byte, hash [32]byte) error {
 	ret := _m.Called(payload, hash)
@@ -186,6 +409,20 @@ func (_m *MockDevicer) FirmwareUpload(payload []byte, hash [32]byte) error {
 	return r0
 }
 
+// FirmwareUploadContext provides a mock function with given fields: ctx, payload, hash
+func (_m *MockDevicer) FirmwareUploadContext(ctx context.Context, payload []byte, hash [32]byte) error {
+	ret := _m.Called(ctx, payload, hash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, [32]byte) error); ok {
+		r0 = rf(ctx, payload, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GenerateMnemonic provides a mock function with given fields: wordCount, usePassphrase
 func (_m *MockDevicer) GenerateMnemonic(wordCount uint32, usePassphrase bool) (wire.Message, error) {
 	ret := _m.Called(wordCount, usePassphrase)
@@ -207,6 +444,27 @@ func (_m *MockDevicer) GenerateMnemonic(wordCount uint32, usePassphrase bool) (w
 	return r0, r1
 }
 
+// GenerateMnemonicContext provides a mock function with given fields: ctx, wordCount, usePassphrase
+func (_m *MockDevicer) GenerateMnemonicContext(ctx context.Context, wordCount uint32, usePassphrase bool) (wire.Message, error) {
+	ret := _m.Called(ctx, wordCount, usePassphrase)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, bool) wire.Message); ok {
+		r0 = rf(ctx, wordCount, usePassphrase)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32, bool) error); ok {
+		r1 = rf(ctx, wordCount, usePassphrase)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetFeatures provides a mock function with given fields:
 func (_m *MockDevicer) GetFeatures() (wire.Message, error) {
 	ret := _m.Called()
@@ -228,6 +486,41 @@ func (_m *MockDevicer) GetFeatures() (wire.Message, error) {
 	return r0, r1
 }
 
+// GetFeaturesContext provides a mock function with given fields: ctx
+func (_m *MockDevicer) GetFeaturesContext(ctx context.Context) (wire.Message, error) {
+	ret := _m.Called(ctx)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context) wire.Message); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OpenSession provides a mock function with given fields:
+func (_m *MockDevicer) OpenSession() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // PassphraseAck provides a mock function with given fields: passphrase
 func (_m *MockDevicer) PassphraseAck(passphrase string) (wire.Message, error) {
 	ret := _m.Called(passphrase)
@@ -249,6 +542,27 @@ func (_m *MockDevicer) PassphraseAck(passphrase string) (wire.Message, error) {
 	return r0, r1
 }
 
+// PassphraseAckContext provides a mock function with given fields: ctx, passphrase
+func (_m *MockDevicer) PassphraseAckContext(ctx context.Context, passphrase string) (wire.Message, error) {
+	ret := _m.Called(ctx, passphrase)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string) wire.Message); ok {
+		r0 = rf(ctx, passphrase)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, passphrase)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PinMatrixAck provides a mock function with given fields: p
 func (_m *MockDevicer) PinMatrixAck(p string) (wire.Message, error) {
 	ret := _m.Called(p)
@@ -270,6 +584,27 @@ func (_m *MockDevicer) PinMatrixAck(p string) (wire.Message, error) {
 	return r0, r1
 }
 
+// PinMatrixAckContext provides a mock function with given fields: ctx, p
+func (_m *MockDevicer) PinMatrixAckContext(ctx context.Context, p string) (wire.Message, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string) wire.Message); ok {
+		r0 = rf(ctx, p)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Recovery provides a mock function with given fields: wordCount, usePassphrase, dryRun
 func (_m *MockDevicer) Recovery(wordCount uint32, usePassphrase bool, dryRun bool) (wire.Message, error) {
 	ret := _m.Called(wordCount, usePassphrase, dryRun)
@@ -291,6 +626,67 @@ func (_m *MockDevicer) Recovery(wordCount uint32, usePassphrase bool, dryRun boo
 	return r0, r1
 }
 
+// RecoveryContext provides a mock function with given fields: ctx, wordCount, usePassphrase, dryRun
+func (_m *MockDevicer) RecoveryContext(ctx context.Context, wordCount uint32, usePassphrase bool, dryRun bool) (wire.Message, error) {
+	ret := _m.Called(ctx, wordCount, usePassphrase, dryRun)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, bool, bool) wire.Message); ok {
+		r0 = rf(ctx, wordCount, usePassphrase, dryRun)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint32, bool, bool) error); ok {
+		r1 = rf(ctx, wordCount, usePassphrase, dryRun)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SendMessage provides a mock function with given fields: msg
+func (_m *MockDevicer) SendMessage(msg wire.Message) (wire.Message, error) {
+	ret := _m.Called(msg)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(wire.Message) wire.Message); ok {
+		r0 = rf(msg)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(wire.Message) error); ok {
+		r1 = rf(msg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetAutoPressButton provides a mock function with given fields: simulateButtonPress, simulateButtonType
+func (_m *MockDevicer) SetAutoPressButton(simulateButtonPress bool, simulateButtonType ButtonType) error {
+	ret := _m.Called(simulateButtonPress, simulateButtonType)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(bool, ButtonType) error); ok {
+		r0 = rf(simulateButtonPress, simulateButtonType)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetInteractor provides a mock function with given fields: interactor
+func (_m *MockDevicer) SetInteractor(interactor Interactor) {
+	_m.Called(interactor)
+}
+
 // SetMnemonic provides a mock function with given fields: mnemonic
 func (_m *MockDevicer) SetMnemonic(mnemonic string) (wire.Message, error) {
 	ret := _m.Called(mnemonic)
@@ -312,20 +708,62 @@ func (_m *MockDevicer) SetMnemonic(mnemonic string) (wire.Message, error) {
 	return r0, r1
 }
 
-// SignMessage provides a mock function with given fields: addressN, message
-func (_m *MockDevicer) SignMessage(addressN int, message string) (wire.Message, error) {
-	ret := _m.Called(addressN, message)
+// SetMnemonicContext provides a mock function with given fields: ctx, mnemonic
+func (_m *MockDevicer) SetMnemonicContext(ctx context.Context, mnemonic string) (wire.Message, error) {
+	ret := _m.Called(ctx, mnemonic)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string) wire.Message); ok {
+		r0 = rf(ctx, mnemonic)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, mnemonic)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SignMessage provides a mock function with given fields: addressIndex, message
+func (_m *MockDevicer) SignMessage(addressIndex int, message string) (wire.Message, error) {
+	ret := _m.Called(addressIndex, message)
 
 	var r0 wire.Message
 	if rf, ok := ret.Get(0).(func(int, string) wire.Message); ok {
-		r0 = rf(addressN, message)
+		r0 = rf(addressIndex, message)
 	} else {
 		r0 = ret.Get(0).(wire.Message)
 	}
 
 	var r1 error
 	if rf, ok := ret.Get(1).(func(int, string) error); ok {
-		r1 = rf(addressN, message)
+		r1 = rf(addressIndex, message)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SignMessageContext provides a mock function with given fields: ctx, addressIndex, message
+func (_m *MockDevicer) SignMessageContext(ctx context.Context, addressIndex int, message string) (wire.Message, error) {
+	ret := _m.Called(ctx, addressIndex, message)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) wire.Message); ok {
+		r0 = rf(ctx, addressIndex, message)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, addressIndex, message)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -354,6 +792,27 @@ func (_m *MockDevicer) TransactionSign(inputs []*messages.SkycoinTransactionInpu
 	return r0, r1
 }
 
+// TransactionSignContext provides a mock function with given fields: ctx, inputs, outputs
+func (_m *MockDevicer) TransactionSignContext(ctx context.Context, inputs []*messages.SkycoinTransactionInput, outputs []*messages.SkycoinTransactionOutput) (wire.Message, error) {
+	ret := _m.Called(ctx, inputs, outputs)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context, []*messages.SkycoinTransactionInput, []*messages.SkycoinTransactionOutput) wire.Message); ok {
+		r0 = rf(ctx, inputs, outputs)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []*messages.SkycoinTransactionInput, []*messages.SkycoinTransactionOutput) error); ok {
+		r1 = rf(ctx, inputs, outputs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Wipe provides a mock function with given fields:
 func (_m *MockDevicer) Wipe() (wire.Message, error) {
 	ret := _m.Called()
@@ -375,6 +834,27 @@ func (_m *MockDevicer) Wipe() (wire.Message, error) {
 	return r0, r1
 }
 
+// WipeContext provides a mock function with given fields: ctx
+func (_m *MockDevicer) WipeContext(ctx context.Context) (wire.Message, error) {
+	ret := _m.Called(ctx)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context) wire.Message); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // WordAck provides a mock function with given fields: word
 func (_m *MockDevicer) WordAck(word string) (wire.Message, error) {
 	ret := _m.Called(word)
@@ -395,3 +875,24 @@ func (_m *MockDevicer) WordAck(word string) (wire.Message, error) {
 
 	return r0, r1
 }
+
+// WordAckContext provides a mock function with given fields: ctx, word
+func (_m *MockDevicer) WordAckContext(ctx context.Context, word string) (wire.Message, error) {
+	ret := _m.Called(ctx, word)
+
+	var r0 wire.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string) wire.Message); ok {
+		r0 = rf(ctx, word)
+	} else {
+		r0 = ret.Get(0).(wire.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, word)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
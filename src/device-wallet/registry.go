@@ -0,0 +1,187 @@
+package devicewallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+// ErrUnknownMessageType is returned for a message type, or a Go type, missing from the registry, see RegisterMessage
+var ErrUnknownMessageType = errors.New("unknown message type")
+
+// registry maps the message types to their Go types, in both directions
+var registry = struct {
+	sync.RWMutex
+	types map[messages.MessageType]reflect.Type
+	kinds map[reflect.Type]messages.MessageType
+}{
+	types: make(map[messages.MessageType]reflect.Type),
+	kinds: make(map[reflect.Type]messages.MessageType),
+}
+
+func init() {
+	for kind, pb := range map[messages.MessageType]proto.Message{
+		messages.MessageType_MessageType_Initialize:                   &messages.Initialize{},
+		messages.MessageType_MessageType_Ping:                         &messages.Ping{},
+		messages.MessageType_MessageType_Success:                      &messages.Success{},
+		messages.MessageType_MessageType_Failure:                      &messages.Failure{},
+		messages.MessageType_MessageType_ChangePin:                    &messages.ChangePin{},
+		messages.MessageType_MessageType_WipeDevice:                   &messages.WipeDevice{},
+		messages.MessageType_MessageType_FirmwareErase:                &messages.FirmwareErase{},
+		messages.MessageType_MessageType_FirmwareUpload:               &messages.FirmwareUpload{},
+		messages.MessageType_MessageType_FirmwareRequest:              &messages.FirmwareRequest{},
+		messages.MessageType_MessageType_Features:                     &messages.Features{},
+		messages.MessageType_MessageType_PinMatrixRequest:             &messages.PinMatrixRequest{},
+		messages.MessageType_MessageType_PinMatrixAck:                 &messages.PinMatrixAck{},
+		messages.MessageType_MessageType_Cancel:                       &messages.Cancel{},
+		messages.MessageType_MessageType_ApplySettings:                &messages.ApplySettings{},
+		messages.MessageType_MessageType_ButtonRequest:                &messages.ButtonRequest{},
+		messages.MessageType_MessageType_ButtonAck:                    &messages.ButtonAck{},
+		messages.MessageType_MessageType_BackupDevice:                 &messages.BackupDevice{},
+		messages.MessageType_MessageType_EntropyRequest:               &messages.EntropyRequest{},
+		messages.MessageType_MessageType_EntropyAck:                   &messages.EntropyAck{},
+		messages.MessageType_MessageType_PassphraseRequest:            &messages.PassphraseRequest{},
+		messages.MessageType_MessageType_PassphraseAck:                &messages.PassphraseAck{},
+		messages.MessageType_MessageType_RecoveryDevice:               &messages.RecoveryDevice{},
+		messages.MessageType_MessageType_WordRequest:                  &messages.WordRequest{},
+		messages.MessageType_MessageType_WordAck:                      &messages.WordAck{},
+		messages.MessageType_MessageType_GetFeatures:                  &messages.GetFeatures{},
+		messages.MessageType_MessageType_SetMnemonic:                  &messages.SetMnemonic{},
+		messages.MessageType_MessageType_SkycoinAddress:               &messages.SkycoinAddress{},
+		messages.MessageType_MessageType_ResponseSkycoinAddress:       &messages.ResponseSkycoinAddress{},
+		messages.MessageType_MessageType_SkycoinCheckMessageSignature: &messages.SkycoinCheckMessageSignature{},
+		messages.MessageType_MessageType_SkycoinSignMessage:           &messages.SkycoinSignMessage{},
+		messages.MessageType_MessageType_ResponseSkycoinSignMessage:   &messages.ResponseSkycoinSignMessage{},
+		messages.MessageType_MessageType_GenerateMnemonic:             &messages.GenerateMnemonic{},
+		messages.MessageType_MessageType_TransactionSign:              &messages.TransactionSign{},
+		messages.MessageType_MessageType_ResponseTransactionSign:      &messages.ResponseTransactionSign{},
+		messages.MessageType_MessageType_DebugLinkDecision:            &messages.DebugLinkDecision{},
+		messages.MessageType_MessageType_DebugLinkGetState:            &messages.DebugLinkGetState{},
+		messages.MessageType_MessageType_DebugLinkState:               &messages.DebugLinkState{},
+		messages.MessageType_MessageType_DebugLinkStop:                &messages.DebugLinkStop{},
+		messages.MessageType_MessageType_DebugLinkLog:                 &messages.DebugLinkLog{},
+	} {
+		RegisterMessage(kind, pb)
+	}
+}
+
+// RegisterMessage registers the Go type of pb as the type of the messages of kind,
+// e.g. for a message added to the firmware after this library
+func RegisterMessage(kind messages.MessageType, pb proto.Message) {
+	registry.Lock()
+	defer registry.Unlock()
+	t := reflect.TypeOf(pb)
+	registry.types[kind] = t
+	registry.kinds[t] = kind
+}
+
+// MessageTypeOf returns the message type of pb
+func MessageTypeOf(pb proto.Message) (messages.MessageType, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+	kind, ok := registry.kinds[reflect.TypeOf(pb)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %T", ErrUnknownMessageType, pb)
+	}
+	return kind, nil
+}
+
+// NewMessage returns a new empty message of the Go type of kind
+func NewMessage(kind messages.MessageType) (proto.Message, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+	t, ok := registry.types[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMessageType, kind)
+	}
+	return reflect.New(t.Elem()).Interface().(proto.Message), nil
+}
+
+// EncodeMessage returns the reports of pb written to the device
+func EncodeMessage(pb proto.Message) ([][64]byte, error) {
+	kind, err := MessageTypeOf(pb)
+	if err != nil {
+		return nil, err
+	}
+	data, err := proto.Marshal(pb)
+	if err != nil {
+		return nil, err
+	}
+	return frameMessage(data, kind)
+}
+
+// DecodeMessage returns msg decoded into the Go type of its kind
+func DecodeMessage(msg wire.Message) (proto.Message, error) {
+	pb, err := NewMessage(messages.MessageType(msg.Kind))
+	if err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(msg.Data, pb); err != nil {
+		return nil, err
+	}
+	return pb, nil
+}
+
+// decodeKind decodes msg when it has the expected kind, caller names the function for the error message
+func decodeKind(msg wire.Message, kind messages.MessageType, caller string) (proto.Message, error) {
+	if msg.Kind != uint16(kind) {
+		return nil, fmt.Errorf("calling %s with wrong message type: %s", caller, messages.MessageType(msg.Kind))
+	}
+	return DecodeMessage(msg)
+}
+
+// Call sends req to the device and returns its answer decoded into the Go type of its kind.
+// The device requests are answered as by the other operations, see SetInteractor.
+// When the device answers with a Failure, the *messages.Failure is returned along with its FailureError.
+func (d *Device) Call(req proto.Message) (proto.Message, error) {
+	if err := d.acquire(); err != nil {
+		return nil, err
+	}
+	defer d.release()
+
+	chunks, err := EncodeMessage(req)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := d.Driver.SendToDevice(d.dev, chunks)
+	if err != nil {
+		return nil, err
+	}
+	msg, err = d.interact(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := DecodeMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+	if failure, ok := resp.(*messages.Failure); ok {
+		return failure, FailureError{
+			Code:    failure.GetCode(),
+			Message: failure.GetMessage(),
+		}
+	}
+	return resp, nil
+}
+
+// CallContext is Call bound to ctx
+func (d *Device) CallContext(ctx context.Context, req proto.Message) (proto.Message, error) {
+	var resp proto.Message
+	_, err := d.withContext(ctx, func() (wire.Message, error) {
+		var err error
+		resp, err = d.Call(req)
+		return wire.Message{}, err
+	})
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+	return resp, err
+}
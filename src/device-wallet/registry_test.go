@@ -0,0 +1,99 @@
+package devicewallet
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+func TestRegistry(t *testing.T) {
+	// every message type is registered in both directions
+	for value, name := range messages.MessageType_name {
+		kind := messages.MessageType(value)
+		pb, err := NewMessage(kind)
+		if err != nil {
+			// the message types without Go type are not registered
+			require.True(t, errors.Is(err, ErrUnknownMessageType), name)
+			continue
+		}
+		registered, err := MessageTypeOf(pb)
+		require.NoError(t, err)
+		require.Equal(t, kind, registered, name)
+	}
+
+	_, err := MessageTypeOf(&messages.SkycoinTransactionInput{})
+	require.True(t, errors.Is(err, ErrUnknownMessageType))
+	_, err = DecodeMessage(wire.Message{Kind: 0xffff})
+	require.True(t, errors.Is(err, ErrUnknownMessageType))
+}
+
+func TestEncodeDecodeMessage(t *testing.T) {
+	req := &messages.SkycoinSignMessage{
+		AddressN: proto.Uint32(3),
+		Message:  proto.String("Hello World!"),
+	}
+	chunks, err := EncodeMessage(req)
+	require.NoError(t, err)
+
+	legacy, err := MessageSignMessage(3, "Hello World!")
+	require.NoError(t, err)
+	require.Equal(t, legacy, chunks)
+
+	dev := &testHelperAnswerDevice{}
+	for _, chunk := range chunks {
+		dev.reports = append(dev.reports, append([]byte(nil), chunk[:]...))
+	}
+	msg, err := readMessage(dev, DefaultLimits())
+	require.NoError(t, err)
+	decoded, err := DecodeMessage(msg)
+	require.NoError(t, err)
+	require.Equal(t, req, decoded)
+}
+
+func TestCall(t *testing.T) {
+	features := &messages.Features{
+		Label:        proto.String("wallet"),
+		MajorVersion: proto.Uint32(1),
+	}
+	dev := &testHelperAnswerDevice{}
+	dev.answer(t, testHelperMessage(t, messages.MessageType_MessageType_Features, features))
+	failure := &messages.Failure{
+		Code:    messages.FailureType_Failure_PinInvalid.Enum(),
+		Message: proto.String("PIN invalid"),
+	}
+	dev.answer(t, testHelperMessage(t, messages.MessageType_MessageType_Failure, failure))
+
+	driverMock := &MockDeviceDriver{}
+	driverMock.On("GetDevice").Return(dev, nil)
+	driverMock.On("SendToDevice", mock.Anything, mustEncode(t, &messages.GetFeatures{})).Return(
+		func(io.ReadWriteCloser, [][64]byte) wire.Message {
+			msg, err := readMessage(dev, DefaultLimits())
+			require.NoError(t, err)
+			return msg
+		}, nil)
+	device := &Device{Driver: driverMock}
+
+	resp, err := device.Call(&messages.GetFeatures{})
+	require.NoError(t, err)
+	require.Equal(t, features, resp)
+
+	resp, err = device.Call(&messages.GetFeatures{})
+	require.True(t, errors.Is(err, ErrPinInvalid))
+	require.Equal(t, failure, resp)
+
+	_, err = device.Call(&messages.SkycoinTransactionInput{})
+	require.True(t, errors.Is(err, ErrUnknownMessageType))
+}
+
+func mustEncode(t *testing.T, pb proto.Message) [][64]byte {
+	chunks, err := EncodeMessage(pb)
+	require.NoError(t, err)
+	return chunks
+}
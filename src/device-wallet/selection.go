@@ -0,0 +1,320 @@
+package devicewallet
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/usb"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+// Selection chooses the device to connect to when several devices are attached.
+// Empty fields match any device, the first device matching all the other fields is used.
+type Selection struct {
+	// Path is the usb.Info.Path of the device
+	Path string
+	// Serial is the USB serial number of the device
+	Serial string
+	// Label is the label reported in the device Features
+	Label string
+	// DeviceID is the device_id reported in the device Features
+	DeviceID string
+}
+
+func (s Selection) String() string {
+	var fields []string
+	if s.Path != "" {
+		fields = append(fields, "path "+s.Path)
+	}
+	if s.Serial != "" {
+		fields = append(fields, "serial "+s.Serial)
+	}
+	if s.Label != "" {
+		fields = append(fields, "label "+s.Label)
+	}
+	if s.DeviceID != "" {
+		fields = append(fields, "device_id "+s.DeviceID)
+	}
+	if len(fields) == 0 {
+		return "any device"
+	}
+	return strings.Join(fields, ", ")
+}
+
+// matchInfo reports whether the usb identity of a device matches the selection
+func (s Selection) matchInfo(info usb.Info) bool {
+	return (s.Path == "" || s.Path == info.Path) &&
+		(s.Serial == "" || s.Serial == info.Serial)
+}
+
+// needsFeatures reports whether the devices have to be asked for their Features to be matched
+func (s Selection) needsFeatures() bool {
+	return s.Label != "" || s.DeviceID != ""
+}
+
+// matchFeatures reports whether the features of a device match the selection
+func (s Selection) matchFeatures(features *messages.Features) bool {
+	return (s.Label == "" || s.Label == features.GetLabel()) &&
+		(s.DeviceID == "" || s.DeviceID == features.GetDeviceId())
+}
+
+const (
+	// DefaultEmulatorPort is the port the emulator listens on by default
+	DefaultEmulatorPort = 21324
+	// emulatorHostEnv is the env var overriding the host of the emulators
+	emulatorHostEnv = "EMULATOR_HOST"
+	// emulatorPortsEnv is the env var overriding the ports of the emulators, a comma separated list
+	emulatorPortsEnv = "EMULATOR_PORTS"
+)
+
+// Option configures a device created by NewDevice
+type Option func(*Driver)
+
+// newDriver returns a driver of the given type configured by options.
+// The emulators are looked for on the host and ports given by the EMULATOR_HOST and EMULATOR_PORTS env vars,
+// 127.0.0.1 and DefaultEmulatorPort when not set.
+func newDriver(deviceType DeviceType, options ...Option) *Driver {
+	drv := &Driver{
+		deviceType:    deviceType,
+		emulatorHost:  os.Getenv(emulatorHostEnv),
+		emulatorPorts: []int{DefaultEmulatorPort},
+	}
+	if ports := os.Getenv(emulatorPortsEnv); ports != "" {
+		if p, err := ParsePorts(ports); err == nil {
+			drv.emulatorPorts = p
+		} else {
+			log.Errorf("invalid %s: %v", emulatorPortsEnv, err)
+		}
+	}
+
+	for _, option := range options {
+		option(drv)
+	}
+	return drv
+}
+
+// ParsePorts parses a comma separated list of ports
+func ParsePorts(s string) ([]int, error) {
+	var ports []int
+	for _, field := range strings.Split(s, ",") {
+		port, err := strconv.ParseUint(strings.TrimSpace(field), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", field)
+		}
+		ports = append(ports, int(port))
+	}
+	return ports, nil
+}
+
+// WithDevicePath selects the device with the given usb.Info.Path
+func WithDevicePath(path string) Option {
+	return func(drv *Driver) {
+		drv.selection.Path = path
+	}
+}
+
+// WithSerial selects the device with the given USB serial number
+func WithSerial(serial string) Option {
+	return func(drv *Driver) {
+		drv.selection.Serial = serial
+	}
+}
+
+// WithLabel selects the device whose Features report the given label
+func WithLabel(label string) Option {
+	return func(drv *Driver) {
+		drv.selection.Label = label
+	}
+}
+
+// WithDeviceID selects the device whose Features report the given device_id
+func WithDeviceID(deviceID string) Option {
+	return func(drv *Driver) {
+		drv.selection.DeviceID = deviceID
+	}
+}
+
+// WithEmulator looks for the emulators on the given ports of host, 127.0.0.1 when empty.
+// The ports are left unchanged when none is given.
+func WithEmulator(host string, ports ...int) Option {
+	return func(drv *Driver) {
+		drv.emulatorHost = host
+		if len(ports) > 0 {
+			drv.emulatorPorts = ports
+		}
+	}
+}
+
+// WithLimits sets the maximum payload sizes of the messages read from the device, DefaultLimits by default
+func WithLimits(limits wire.Limits) Option {
+	return func(drv *Driver) {
+		drv.limits = &limits
+	}
+}
+
+// WithBus connects to the devices attached to b instead of the local WebUSB and HIDAPI buses, or the emulators
+func WithBus(b *usb.USB) Option {
+	return func(drv *Driver) {
+		drv.bus = b
+	}
+}
+
+// DeviceInfo identifies an attached device
+type DeviceInfo struct {
+	Type DeviceType
+	usb.Info
+	// Features reported by the device, nil when it could not be queried
+	Features *messages.Features
+}
+
+// ListDevices returns every hardware wallet attached through USB and every emulator along with their features,
+// options locate the devices as they do for NewDevice. The devices of a bus given WithBus are listed once, as USB devices.
+func ListDevices(options ...Option) ([]DeviceInfo, error) {
+	deviceTypes := []DeviceType{DeviceTypeUSB, DeviceTypeEmulator}
+	if newDriver(DeviceTypeUSB, options...).bus != nil {
+		// both device types would enumerate the same bus
+		deviceTypes = deviceTypes[:1]
+	}
+
+	var devices []DeviceInfo
+	for _, deviceType := range deviceTypes {
+		b, err := newDriver(deviceType, options...).getBus()
+		if err != nil {
+			return nil, err
+		}
+
+		infos, err := b.Enumerate()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, info := range infos {
+			device := DeviceInfo{
+				Type: deviceType,
+				Info: info,
+			}
+
+			dev, err := connectBusDevice(b, info.Path)
+			if err != nil {
+				log.Errorf("connecting to %s: %v", info.Path, err)
+				devices = append(devices, device)
+				continue
+			}
+			device.Features, err = getFeatures(dev)
+			if err != nil {
+				log.Errorf("getting features of %s: %v", info.Path, err)
+			}
+			if err := dev.Close(); err != nil {
+				log.Errorf("closing %s: %v", info.Path, err)
+			}
+			devices = append(devices, device)
+		}
+	}
+	return devices, nil
+}
+
+// WatchDevices emits an event every time a hardware wallet is attached through USB or detached,
+// see usb.USB.Watch. It does not connect to the devices.
+func WatchDevices(ctx context.Context) (<-chan usb.Event, error) {
+	b, err := newUsbBus()
+	if err != nil {
+		return nil, err
+	}
+	return b.Watch(ctx)
+}
+
+// newUsbBus returns the buses the hardware wallets are attached to
+func newUsbBus() (*usb.USB, error) {
+	w, err := usb.InitWebUSB()
+	if err != nil {
+		log.Printf("webusb: %s", err)
+		return nil, err
+	}
+	h, err := usb.InitHIDAPI()
+	if err != nil {
+		log.Printf("hidapi: %s", err)
+		return nil, err
+	}
+	return usb.Init(w, h), nil
+}
+
+// connectBusDevice connects to the device attached to b at path, retrying a few times
+func connectBusDevice(b *usb.USB, path string) (usb.Device, error) {
+	var err error
+	for tries := 0; tries < 3; tries++ {
+		var dev usb.Device
+		dev, err = b.Connect(path)
+		if err == nil {
+			return dev, nil
+		}
+		log.Print(err.Error())
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil, err
+}
+
+// getFeatures asks the device for its features
+func getFeatures(dev io.ReadWriteCloser) (*messages.Features, error) {
+	chunks, err := MessageGetFeatures()
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := sendToDevice(dev, chunks, DefaultLimits())
+	if err != nil {
+		return nil, err
+	}
+
+	features := &messages.Features{}
+	if err := decodeResponse(msg, messages.MessageType_MessageType_Features, features); err != nil {
+		return nil, err
+	}
+	return features, nil
+}
+
+// getBusDevice returns a connection to the first device attached to b matching the selection
+func getBusDevice(b *usb.USB, selection Selection) (usb.Device, error) {
+	infos, err := b.Enumerate()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range infos {
+		if !selection.matchInfo(info) {
+			continue
+		}
+
+		dev, err := connectBusDevice(b, info.Path)
+		if err != nil {
+			if selection.needsFeatures() {
+				continue
+			}
+			return nil, err
+		}
+		if !selection.needsFeatures() {
+			return dev, nil
+		}
+
+		features, err := getFeatures(dev)
+		if err == nil && selection.matchFeatures(features) {
+			return dev, nil
+		}
+		if err != nil {
+			log.Errorf("getting features of %s: %v", info.Path, err)
+		}
+		if err := dev.Close(); err != nil {
+			log.Errorf("closing %s: %v", info.Path, err)
+		}
+	}
+
+	if len(infos) == 0 {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("no device matching %s: %w", selection, usb.ErrNotFound)
+}
@@ -0,0 +1,91 @@
+package devicewallet
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/usb"
+)
+
+func TestNewDeviceOptions(t *testing.T) {
+	device := NewDevice(DeviceTypeUSB, WithDevicePath("web0102"), WithSerial("ABCD"), WithLabel("desk"), WithDeviceID("42"))
+	require.Equal(t, Selection{
+		Path:     "web0102",
+		Serial:   "ABCD",
+		Label:    "desk",
+		DeviceID: "42",
+	}, device.Driver.(*Driver).selection)
+	require.Equal(t, "path web0102, serial ABCD, label desk, device_id 42", device.Driver.(*Driver).selection.String())
+
+	device = NewDevice(DeviceTypeUSB)
+	require.Equal(t, Selection{}, device.Driver.(*Driver).selection)
+	require.Equal(t, "any device", device.Driver.(*Driver).selection.String())
+}
+
+func TestSelectionMatch(t *testing.T) {
+	info := usb.Info{Path: "hid1234", Serial: "ABCD"}
+	features := &messages.Features{
+		Label:    proto.String("desk"),
+		DeviceId: proto.String("42"),
+	}
+
+	tt := []struct {
+		name      string
+		selection Selection
+		info      bool
+		features  bool
+	}{
+		{"any", Selection{}, true, true},
+		{"path", Selection{Path: "hid1234"}, true, true},
+		{"other path", Selection{Path: "hid5678"}, false, true},
+		{"serial", Selection{Serial: "ABCD"}, true, true},
+		{"other serial", Selection{Path: "hid1234", Serial: "EFGH"}, false, true},
+		{"label", Selection{Label: "desk"}, true, true},
+		{"other label", Selection{Label: "travel"}, true, false},
+		{"device id", Selection{Label: "desk", DeviceID: "42"}, true, true},
+		{"other device id", Selection{DeviceID: "43"}, true, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.info, tc.selection.matchInfo(info))
+			require.Equal(t, tc.features, tc.selection.matchFeatures(features))
+			require.Equal(t, tc.selection.Label != "" || tc.selection.DeviceID != "", tc.selection.needsFeatures())
+		})
+	}
+}
+
+func TestEmulatorOptions(t *testing.T) {
+	ports, err := ParsePorts("21324, 21325,21326")
+	require.NoError(t, err)
+	require.Equal(t, []int{21324, 21325, 21326}, ports)
+	_, err = ParsePorts("21324,emulator")
+	require.EqualError(t, err, `invalid port "emulator"`)
+	_, err = ParsePorts("70000")
+	require.Error(t, err)
+
+	os.Setenv(emulatorHostEnv, "")
+	os.Setenv(emulatorPortsEnv, "")
+	driver := NewDevice(DeviceTypeEmulator).Driver.(*Driver)
+	require.Equal(t, "", driver.emulatorHost)
+	require.Equal(t, []int{DefaultEmulatorPort}, driver.emulatorPorts)
+
+	os.Setenv(emulatorHostEnv, "10.0.0.2")
+	os.Setenv(emulatorPortsEnv, "21400,21401")
+	defer os.Unsetenv(emulatorHostEnv)
+	defer os.Unsetenv(emulatorPortsEnv)
+	driver = NewDevice(DeviceTypeEmulator).Driver.(*Driver)
+	require.Equal(t, "10.0.0.2", driver.emulatorHost)
+	require.Equal(t, []int{21400, 21401}, driver.emulatorPorts)
+
+	driver = NewDevice(DeviceTypeEmulator, WithEmulator("emulators.ci", 21500)).Driver.(*Driver)
+	require.Equal(t, "emulators.ci", driver.emulatorHost)
+	require.Equal(t, []int{21500}, driver.emulatorPorts)
+
+	driver = NewDevice(DeviceTypeEmulator, WithEmulator("emulators.ci")).Driver.(*Driver)
+	require.Equal(t, []int{21400, 21401}, driver.emulatorPorts)
+}
@@ -0,0 +1,92 @@
+package devicewallet
+
+import "errors"
+
+// ErrSessionOpen is returned when opening a session on a device which already has one
+var ErrSessionOpen = errors.New("session already open")
+
+// OpenSession connects to the device and keeps the connection open until CloseSession is called.
+// All the operations, including the PIN, passphrase and button exchanges, run on this connection.
+// Without session every operation connects to the device and closes the connection when done.
+func (d *Device) OpenSession() error {
+	if d.session {
+		return ErrSessionOpen
+	}
+	if d.depth == 0 {
+		if err := d.Connect(); err != nil {
+			return err
+		}
+	}
+	d.session = true
+	return nil
+}
+
+// CloseSession closes the connection opened by OpenSession
+func (d *Device) CloseSession() error {
+	if !d.session {
+		return nil
+	}
+	d.session = false
+	if d.depth > 0 {
+		// closed by the ongoing operation
+		return nil
+	}
+	return d.disconnect()
+}
+
+// acquire makes sure there is a connection to the device for an operation,
+// the connection is reused by the operations nested in it, e.g. the acks sent
+// by the Interactor, and by all the operations of a session.
+// No connection is opened once the context of the operation is done, see withContext.
+func (d *Device) acquire() error {
+	if err := d.contextErr(); err != nil {
+		return err
+	}
+	if d.dev == nil || (d.depth == 0 && !d.session) {
+		if err := d.Connect(); err != nil {
+			return err
+		}
+		// the context is done while connecting, withContext may not have seen the connection to cancel
+		if err := d.contextErr(); err != nil {
+			if d.depth == 0 && !d.session {
+				if err := d.disconnect(); err != nil {
+					log.Errorf("closing connection: %v", err)
+				}
+			}
+			return err
+		}
+	}
+	d.depth++
+	return nil
+}
+
+// release closes the connection once the outermost operation is done, unless a session is open.
+// A broken connection is closed even in a session, the next operation connects again.
+func (d *Device) release() {
+	d.depth--
+	if d.depth > 0 {
+		return
+	}
+	if d.session {
+		if c := d.connection(); c == nil || !c.broken() {
+			return
+		}
+		log.Errorf("connection broken, the next operation connects again")
+	}
+	if err := d.disconnect(); err != nil {
+		log.Errorf("closing connection: %v", err)
+	}
+}
+
+// disconnect closes the connection to the device
+func (d *Device) disconnect() error {
+	d.devMu.Lock()
+	dev := d.dev
+	d.dev = nil
+	d.devMu.Unlock()
+
+	if dev == nil {
+		return nil
+	}
+	return dev.Close()
+}
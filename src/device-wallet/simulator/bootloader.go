@@ -0,0 +1,75 @@
+package simulator
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/firmware"
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+// SetBootloader restarts the simulated device in bootloader mode, as when it is plugged in with
+// both buttons held, or back to its firmware
+func (s *Simulator) SetBootloader(bootloader bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restart(bootloader)
+}
+
+// Bootloader reports whether the simulated device runs its bootloader
+func (s *Simulator) Bootloader() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bootloader
+}
+
+// restart ends the session and starts the bootloader or the firmware
+func (s *Simulator) restart(bootloader bool) {
+	s.reset()
+	s.pinCached = false
+	s.passphraseCached = false
+	s.bootloader = bootloader
+	s.firmwareErased = false
+}
+
+// handleBootloader processes the messages only handled by the bootloader
+func (s *Simulator) handleBootloader(msg wire.Message) *wire.Message {
+	switch messages.MessageType(msg.Kind) {
+	case messages.MessageType_MessageType_FirmwareErase:
+		s.firmwareErased = true
+		return success("Firmware erased")
+	case messages.MessageType_MessageType_FirmwareUpload:
+		return s.firmwareUpload(msg)
+	default:
+		return failure(messages.FailureType_Failure_UnexpectedMessage, "Unexpected message")
+	}
+}
+
+// firmwareUpload installs the uploaded image once the user confirms its hash, the device then restarts
+// on its new firmware, reporting the version of its configuration as the image does not hold one
+func (s *Simulator) firmwareUpload(msg wire.Message) *wire.Message {
+	if !s.firmwareErased {
+		return failure(messages.FailureType_Failure_UnexpectedMessage, "Firmware not erased")
+	}
+
+	var upload messages.FirmwareUpload
+	if err := proto.Unmarshal(msg.Data, &upload); err != nil {
+		return failure(messages.FailureType_Failure_DataError, err.Error())
+	}
+	img, err := firmware.Parse(upload.GetPayload())
+	if err != nil {
+		return failure(messages.FailureType_Failure_FirmwareError, "Invalid firmware header")
+	}
+	hash := sha256.Sum256(img.Code())
+	if !bytes.Equal(hash[:], upload.GetHash()) {
+		return failure(messages.FailureType_Failure_FirmwareError, "Invalid firmware hash")
+	}
+
+	return s.button(messages.ButtonRequestType_ButtonRequest_FirmwareCheck, func() *wire.Message {
+		s.restart(false)
+		return success("New firmware successfully installed")
+	})
+}
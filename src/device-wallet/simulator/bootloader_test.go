@@ -0,0 +1,113 @@
+package simulator
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	devicewallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/firmware"
+)
+
+// makeFirmware returns an unsigned image, its version set as known by the caller
+func makeFirmware(t *testing.T, version firmware.Version, codeLength int) *firmware.Image {
+	data := make([]byte, firmware.HeaderSize+codeLength)
+	copy(data, firmware.Magic)
+	binary.LittleEndian.PutUint32(data[4:], uint32(codeLength))
+	for i := firmware.HeaderSize; i < len(data); i++ {
+		data[i] = byte(i)
+	}
+	img, err := firmware.Parse(data)
+	require.NoError(t, err)
+	img.Version = version
+	return img
+}
+
+func TestUpdateFirmware(t *testing.T) {
+	config := DefaultConfig()
+	config.Bootloader = true
+	sim := NewWithConfig(config)
+	device := NewDevice(sim)
+
+	bootloader, err := device.Bootloader()
+	require.NoError(t, err)
+	require.True(t, bootloader)
+
+	// the simulator restarts on the version of its configuration
+	version := firmware.Version{Major: 1, Minor: 7, Patch: 0}
+	var stages []devicewallet.UpdateStage
+	var last devicewallet.UpdateProgress
+	features, err := device.UpdateFirmware(makeFirmware(t, version, 3000), devicewallet.UpdateOptions{
+		Progress: func(progress devicewallet.UpdateProgress) {
+			if len(stages) == 0 || stages[len(stages)-1] != progress.Stage {
+				stages = append(stages, progress.Stage)
+			}
+			if progress.Stage == devicewallet.UpdateStageUpload {
+				last = progress
+			}
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []devicewallet.UpdateStage{
+		devicewallet.UpdateStageErase,
+		devicewallet.UpdateStageUpload,
+		devicewallet.UpdateStageConfirm,
+		devicewallet.UpdateStageRestart,
+		devicewallet.UpdateStageDone,
+	}, stages)
+	require.True(t, last.Total > 3000)
+	require.Equal(t, last.Total, last.Sent)
+
+	require.False(t, features.GetBootloaderMode())
+	require.Equal(t, uint32(1), features.GetMajorVersion())
+	require.Equal(t, uint32(7), features.GetMinorVersion())
+	require.Equal(t, uint32(0), features.GetPatchVersion())
+	require.False(t, sim.Bootloader())
+
+	// the firmware is only updated from the bootloader
+	_, err = device.UpdateFirmware(makeFirmware(t, version, 10), devicewallet.UpdateOptions{})
+	require.Equal(t, devicewallet.ErrNotInBootloader, err)
+}
+
+func TestUpdateFirmwareWithoutVersion(t *testing.T) {
+	config := DefaultConfig()
+	config.Bootloader = true
+	sim := NewWithConfig(config)
+	device := NewDevice(sim)
+
+	// the version the device restarts on is not checked
+	features, err := device.UpdateFirmware(makeFirmware(t, firmware.Version{}, 100), devicewallet.UpdateOptions{})
+	require.NoError(t, err)
+	require.False(t, features.GetBootloaderMode())
+
+	sim.SetBootloader(true)
+	features, err = device.UpdateFirmware(makeFirmware(t, firmware.Version{Major: 2}, 100), devicewallet.UpdateOptions{})
+	require.True(t, errors.Is(err, devicewallet.ErrVersionMismatch), "%v", err)
+	require.False(t, features.GetBootloaderMode())
+}
+
+func TestFirmwareUploadFailures(t *testing.T) {
+	config := DefaultConfig()
+	config.Bootloader = true
+	sim := NewWithConfig(config)
+	device := NewDevice(sim)
+	img := makeFirmware(t, firmware.Version{Major: 1, Minor: 8}, 100)
+
+	// a hash not matching the code is refused by the device
+	err := device.FirmwareUpload(img.Data, sha256.Sum256(img.Data))
+	require.True(t, errors.Is(err, devicewallet.ErrFirmwareError), "%v", err)
+	require.True(t, sim.Bootloader())
+
+	// the user refuses the hash
+	require.NoError(t, device.SetAutoPressButton(true, devicewallet.ButtonLeft))
+	err = device.FirmwareUpload(img.Data, img.Hash())
+	require.True(t, errors.Is(err, devicewallet.ErrActionCancelled), "%v", err)
+	require.True(t, sim.Bootloader())
+
+	require.NoError(t, device.SetAutoPressButton(true, devicewallet.ButtonRight))
+	require.NoError(t, device.FirmwareUpload(img.Data, img.Hash()))
+	require.False(t, sim.Bootloader())
+}
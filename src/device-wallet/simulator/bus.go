@@ -0,0 +1,49 @@
+package simulator
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/usb"
+)
+
+const busPrefix = "simulator"
+
+// Bus is a usb.Bus to which simulated devices are attached,
+// the path of the simulator at index i is "simulator<i>"
+type Bus struct {
+	sims []*Simulator
+}
+
+// NewBus returns a bus with the given simulators attached
+func NewBus(sims ...*Simulator) *Bus {
+	return &Bus{
+		sims: sims,
+	}
+}
+
+// Enumerate returns the simulators attached to the bus
+func (b *Bus) Enumerate() ([]usb.Info, error) {
+	infos := make([]usb.Info, len(b.sims))
+	for i := range b.sims {
+		infos[i] = usb.Info{
+			Path:   busPrefix + strconv.Itoa(i),
+			Serial: "SIMULATOR" + strconv.Itoa(i),
+		}
+	}
+	return infos, nil
+}
+
+// Connect returns a new connection to the simulator at path
+func (b *Bus) Connect(path string) (usb.Device, error) {
+	i, err := strconv.Atoi(strings.TrimPrefix(path, busPrefix))
+	if err != nil || i < 0 || i >= len(b.sims) {
+		return nil, usb.ErrNotFound
+	}
+	return b.sims[i].Open(), nil
+}
+
+// Has reports whether path is the path of a simulator
+func (b *Bus) Has(path string) bool {
+	return strings.HasPrefix(path, busPrefix)
+}
@@ -0,0 +1,121 @@
+package simulator
+
+import (
+	"io"
+
+	"github.com/gogo/protobuf/proto"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+// OpenDebugLink returns a new connection to the DebugLink of the simulated device.
+// It answers DebugLinkGetState with the device state, DebugLinkDecision presses the button,
+// when no button request is acknowledged yet the decision waits for the next one.
+func (s *Simulator) OpenDebugLink() io.ReadWriteCloser {
+	return &debugConn{
+		sim: s,
+		in:  wire.NewDecoder(nil, wire.ReportSize),
+	}
+}
+
+// debugState returns the state reported through the DebugLink
+func (s *Simulator) debugState() *messages.DebugLinkState {
+	state := &messages.DebugLinkState{
+		PassphraseProtection: proto.Bool(s.passphraseProtection),
+	}
+	if s.pin != "" {
+		state.Pin = proto.String(s.pin)
+	}
+	if s.matrix != "" {
+		state.Matrix = proto.String(s.matrix)
+	}
+	if s.mnemonic != "" {
+		state.Mnemonic = proto.String(s.mnemonic)
+	}
+	if s.resetWord != "" {
+		state.ResetWord = proto.String(s.resetWord)
+	}
+	if s.recoveryWordPos != 0 {
+		state.RecoveryWordPos = proto.Uint32(s.recoveryWordPos)
+	}
+	return state
+}
+
+// handleDebug processes a DebugLink message and returns the answer, if any
+func (s *Simulator) handleDebug(msg wire.Message) *wire.Message {
+	switch messages.MessageType(msg.Kind) {
+	case messages.MessageType_MessageType_DebugLinkDecision:
+		var decision messages.DebugLinkDecision
+		if err := proto.Unmarshal(msg.Data, &decision); err != nil {
+			return failure(messages.FailureType_Failure_DataError, err.Error())
+		}
+		if s.press != nil {
+			s.pressLocked(decision.GetYesNo())
+		} else {
+			s.decisions = append(s.decisions, decision.GetYesNo())
+		}
+		return nil
+	case messages.MessageType_MessageType_DebugLinkGetState:
+		return reply(messages.MessageType_MessageType_DebugLinkState, s.debugState())
+	case messages.MessageType_MessageType_DebugLinkStop:
+		return nil
+	default:
+		return failure(messages.FailureType_Failure_UnexpectedMessage, "Unexpected message")
+	}
+}
+
+// debugConn is a connection to the DebugLink of the simulated device
+type debugConn struct {
+	sim *Simulator
+	in  *wire.Decoder
+
+	// guarded by sim.mu
+	out    [][wire.ReportSize]byte
+	closed bool
+}
+
+func (c *debugConn) Write(p []byte) (int, error) {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+	if c.closed {
+		return 0, ErrClosed
+	}
+
+	msg, err := c.in.Feed(p)
+	if err != nil {
+		return 0, err
+	}
+	if msg != nil {
+		if answer := c.sim.handleDebug(*msg); answer != nil {
+			if _, err := answer.WriteTo(reportWriter{&c.out}); err != nil {
+				return 0, err
+			}
+		}
+		c.sim.cond.Broadcast()
+	}
+	return len(p), nil
+}
+
+func (c *debugConn) Read(p []byte) (int, error) {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+	for len(c.out) == 0 {
+		if c.closed {
+			return 0, ErrClosed
+		}
+		c.sim.cond.Wait()
+	}
+
+	report := c.out[0]
+	c.out = c.out[1:]
+	return copy(p, report[:]), nil
+}
+
+func (c *debugConn) Close() error {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+	c.closed = true
+	c.sim.cond.Broadcast()
+	return nil
+}
@@ -0,0 +1,105 @@
+package simulator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	devicewallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+)
+
+// newDebugDevice returns a device whose buttons are only pressed through its DebugLink
+func newDebugDevice(t *testing.T) (*Simulator, *devicewallet.Device, *devicewallet.DebugInteractor) {
+	config := DefaultConfig()
+	config.AutoPress = false
+	sim := NewWithConfig(config)
+	device := NewDevice(sim)
+
+	link, err := device.DebugLink()
+	require.NoError(t, err)
+	interactor := &devicewallet.DebugInteractor{Link: link}
+	device.SetInteractor(interactor)
+	return sim, device, interactor
+}
+
+func TestDebugLinkChangePin(t *testing.T) {
+	sim, device, interactor := newDebugDevice(t)
+	defer interactor.Link.Close()
+
+	msg, err := device.SetMnemonic(testMnemonic)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Success, msg)
+
+	interactor.Pin = "1234"
+	msg, err = device.ChangePin()
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Success, msg)
+
+	state, err := interactor.Link.State()
+	require.NoError(t, err)
+	require.Equal(t, "1234", state.GetPin())
+	require.Equal(t, testMnemonic, state.GetMnemonic())
+
+	// Backup initializes the device, the PIN is entered on a new matrix
+	_, err = device.Backup()
+	require.NoError(t, err)
+	msg, err = device.AddressGen(1, 0, false)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_ResponseSkycoinAddress, msg)
+	require.Equal(t, testMnemonic, sim.Mnemonic())
+}
+
+func TestDebugLinkRecovery(t *testing.T) {
+	sim, device, interactor := newDebugDevice(t)
+	defer interactor.Link.Close()
+
+	interactor.Mnemonic = testMnemonic
+	msg, err := device.Recovery(12, false, false)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Success, msg)
+	require.Equal(t, testMnemonic, sim.Mnemonic())
+
+	state, err := interactor.Link.State()
+	require.NoError(t, err)
+	require.Zero(t, state.GetRecoveryWordPos())
+
+	// the dry run checks the words against the seed of the device
+	msg, err = device.Recovery(12, false, true)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Success, msg)
+}
+
+func TestDebugLinkBackup(t *testing.T) {
+	sim, device, interactor := newDebugDevice(t)
+	defer interactor.Link.Close()
+
+	msg, err := device.GenerateMnemonic(12, false)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Success, msg)
+
+	msg, err = device.Backup()
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Success, msg)
+	require.Equal(t, sim.Mnemonic(), strings.Join(interactor.Words, " "))
+
+	state, err := interactor.Link.State()
+	require.NoError(t, err)
+	require.Empty(t, state.GetResetWord())
+}
+
+func TestDebugLinkReject(t *testing.T) {
+	sim, device, interactor := newDebugDevice(t)
+	defer interactor.Link.Close()
+
+	interactor.Reject = true
+	msg, err := device.SetMnemonic(testMnemonic)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Failure, msg)
+	require.Empty(t, sim.Mnemonic())
+
+	// without PIN matrix displayed there is no layout to read
+	_, err = interactor.Link.Matrix()
+	require.EqualError(t, err, "no PIN matrix displayed")
+}
@@ -0,0 +1,46 @@
+package simulator
+
+import (
+	"io"
+
+	devicewallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+)
+
+// Driver is a devicewallet.DeviceDriver connected to a simulated device.
+// It reports itself as an emulator, so button presses can be simulated with Device.SetAutoPressButton.
+type Driver struct {
+	devicewallet.Driver
+	sim *Simulator
+}
+
+// NewDriver returns a driver connected to the given simulator
+func NewDriver(sim *Simulator) *Driver {
+	return &Driver{sim: sim}
+}
+
+// NewDevice returns a devicewallet.Device backed by the given simulator
+func NewDevice(sim *Simulator) *devicewallet.Device {
+	return &devicewallet.Device{
+		Driver: NewDriver(sim),
+	}
+}
+
+// Simulator returns the simulated device
+func (drv *Driver) Simulator() *Simulator {
+	return drv.sim
+}
+
+// DeviceType return driver device type
+func (drv *Driver) DeviceType() devicewallet.DeviceType {
+	return devicewallet.DeviceTypeEmulator
+}
+
+// GetDevice returns a new connection to the simulated device
+func (drv *Driver) GetDevice() (io.ReadWriteCloser, error) {
+	return drv.sim.Open(), nil
+}
+
+// GetDebugLink returns a new connection to the DebugLink of the simulated device
+func (drv *Driver) GetDebugLink() (io.ReadWriteCloser, error) {
+	return drv.sim.OpenDebugLink(), nil
+}
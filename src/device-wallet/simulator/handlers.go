@@ -0,0 +1,666 @@
+package simulator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/skycoin/skycoin/src/cipher"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+const vendor = "Skycoin Foundation"
+
+// wordlist used to build the generated mnemonics.
+// These are the first 64 words of the BIP39 english wordlist, the mnemonics generated
+// by the simulator are not checksummed BIP39 mnemonics, they are only meant to be
+// recovered, backed up and used as seeds.
+var wordlist = []string{
+	"abandon", "ability", "able", "about", "above", "absent", "absorb", "abstract",
+	"absurd", "abuse", "access", "accident", "account", "accuse", "achieve", "acid",
+	"acoustic", "acquire", "across", "act", "action", "actor", "actress", "actual",
+	"adapt", "add", "addict", "address", "adjust", "admit", "adult", "advance",
+	"advice", "aerobic", "affair", "afford", "afraid", "again", "age", "agent",
+	"agree", "ahead", "aim", "air", "airport", "aisle", "alarm", "album",
+	"alcohol", "alert", "alien", "all", "alley", "allow", "almost", "alone",
+	"alpha", "already", "also", "alter", "always", "amateur", "amazing", "among",
+}
+
+// handle processes a message sent by the host and returns the answer, if any
+func (s *Simulator) handle(msg wire.Message) *wire.Message {
+	kind := messages.MessageType(msg.Kind)
+
+	switch kind {
+	case messages.MessageType_MessageType_Initialize:
+		s.reset()
+		s.pinCached = false
+		s.passphraseCached = false
+		return s.features()
+	case messages.MessageType_MessageType_GetFeatures:
+		return s.features()
+	case messages.MessageType_MessageType_Cancel:
+		s.reset()
+		return failure(messages.FailureType_Failure_ActionCancelled, "Action cancelled by user")
+	}
+
+	if s.pending != nil {
+		p := s.pending
+		s.pending = nil
+		if kind != p.expect {
+			s.press = nil
+			return failure(messages.FailureType_Failure_UnexpectedMessage, "Unexpected message")
+		}
+		return p.next(msg)
+	}
+
+	if s.bootloader {
+		return s.handleBootloader(msg)
+	}
+
+	switch kind {
+	case messages.MessageType_MessageType_Ping:
+		return s.ping(msg)
+	case messages.MessageType_MessageType_SetMnemonic:
+		return s.setMnemonic(msg)
+	case messages.MessageType_MessageType_GenerateMnemonic:
+		return s.generateMnemonic(msg)
+	case messages.MessageType_MessageType_SkycoinAddress:
+		return s.skycoinAddress(msg)
+	case messages.MessageType_MessageType_SkycoinSignMessage:
+		return s.signMessage(msg)
+	case messages.MessageType_MessageType_SkycoinCheckMessageSignature:
+		return s.checkMessageSignature(msg)
+	case messages.MessageType_MessageType_TransactionSign:
+		return s.transactionSign(msg)
+	case messages.MessageType_MessageType_ChangePin:
+		return s.changePin(msg)
+	case messages.MessageType_MessageType_WipeDevice:
+		return s.wipe()
+	case messages.MessageType_MessageType_BackupDevice:
+		return s.backup()
+	case messages.MessageType_MessageType_RecoveryDevice:
+		return s.recovery(msg)
+	case messages.MessageType_MessageType_ApplySettings:
+		return s.applySettings(msg)
+	default:
+		return failure(messages.FailureType_Failure_UnexpectedMessage, "Unexpected message")
+	}
+}
+
+func (s *Simulator) features() *wire.Message {
+	return reply(messages.MessageType_MessageType_Features, &messages.Features{
+		Vendor:               proto.String(vendor),
+		MajorVersion:         proto.Uint32(s.config.MajorVersion),
+		MinorVersion:         proto.Uint32(s.config.MinorVersion),
+		PatchVersion:         proto.Uint32(s.config.PatchVersion),
+		BootloaderMode:       proto.Bool(s.bootloader),
+		DeviceId:             proto.String(s.deviceID),
+		PinProtection:        proto.Bool(s.pin != ""),
+		PassphraseProtection: proto.Bool(s.passphraseProtection),
+		Label:                proto.String(s.label),
+		Initialized:          proto.Bool(s.mnemonic != ""),
+		PinCached:            proto.Bool(s.pinCached),
+		PassphraseCached:     proto.Bool(s.passphraseCached),
+		FirmwarePresent:      proto.Bool(!s.firmwareErased),
+		NeedsBackup:          proto.Bool(s.needsBackup),
+	})
+}
+
+// reset ends the ongoing operation
+func (s *Simulator) reset() {
+	s.pending = nil
+	s.press = nil
+	s.decisions = nil
+	s.resetWord = ""
+	s.recoveryWordPos = 0
+}
+
+// expect registers the continuation of an operation waiting for a message of the given kind
+func (s *Simulator) expect(kind messages.MessageType, next func(msg wire.Message) *wire.Message) {
+	s.pending = &prompt{
+		expect: kind,
+		next:   next,
+	}
+}
+
+// button asks the host to acknowledge a button request, next is called once the button is pressed
+func (s *Simulator) button(code messages.ButtonRequestType, next func() *wire.Message) *wire.Message {
+	s.expect(messages.MessageType_MessageType_ButtonAck, func(wire.Message) *wire.Message {
+		s.press = func(confirmed bool) *wire.Message {
+			if !confirmed {
+				return failure(messages.FailureType_Failure_ActionCancelled, "Action cancelled by user")
+			}
+			return next()
+		}
+		// a DebugLink decision sent before the button request was acknowledged presses the button
+		if len(s.decisions) > 0 {
+			confirmed := s.decisions[0]
+			s.decisions = s.decisions[1:]
+			s.pressLocked(confirmed)
+		}
+		return nil
+	})
+	return reply(messages.MessageType_MessageType_ButtonRequest, &messages.ButtonRequest{
+		Code: code.Enum(),
+	})
+}
+
+// requestPin shows a new PIN matrix and passes the decoded PIN to next
+func (s *Simulator) requestPin(t messages.PinMatrixRequestType, next func(pin string) *wire.Message) *wire.Message {
+	digits := []byte("123456789")
+	s.rand.Shuffle(len(digits), func(i, j int) {
+		digits[i], digits[j] = digits[j], digits[i]
+	})
+	s.matrix = string(digits)
+
+	s.expect(messages.MessageType_MessageType_PinMatrixAck, func(msg wire.Message) *wire.Message {
+		var ack messages.PinMatrixAck
+		if err := proto.Unmarshal(msg.Data, &ack); err != nil {
+			return failure(messages.FailureType_Failure_DataError, err.Error())
+		}
+		pin, ok := s.decodePin(ack.GetPin())
+		s.matrix = ""
+		if !ok {
+			return failure(messages.FailureType_Failure_PinInvalid, "PIN invalid")
+		}
+		return next(pin)
+	})
+	return reply(messages.MessageType_MessageType_PinMatrixRequest, &messages.PinMatrixRequest{
+		Type: t.Enum(),
+	})
+}
+
+// decodePin maps the matrix positions sent by the host to the PIN digits
+func (s *Simulator) decodePin(positions string) (string, bool) {
+	if positions == "" || len(positions) > 9 {
+		return "", false
+	}
+	pin := make([]byte, len(positions))
+	for i := 0; i < len(positions); i++ {
+		c := positions[i]
+		if c < '1' || c > '9' {
+			return "", false
+		}
+		pin[i] = s.matrix[c-'1']
+	}
+	return string(pin), true
+}
+
+// requirePin asks for the current PIN when the device is protected and the PIN is not cached
+func (s *Simulator) requirePin(next func() *wire.Message) *wire.Message {
+	if s.pin == "" || s.pinCached {
+		return next()
+	}
+	return s.requestPin(messages.PinMatrixRequestType_PinMatrixRequestType_Current, func(pin string) *wire.Message {
+		if pin != s.pin {
+			return failure(messages.FailureType_Failure_PinInvalid, "PIN invalid")
+		}
+		s.pinCached = true
+		return next()
+	})
+}
+
+// requirePassphrase asks for the passphrase when the device is protected and the passphrase is not cached.
+// The passphrase is accepted but does not alter the derived keys.
+func (s *Simulator) requirePassphrase(next func() *wire.Message) *wire.Message {
+	if !s.passphraseProtection || s.passphraseCached {
+		return next()
+	}
+	s.expect(messages.MessageType_MessageType_PassphraseAck, func(msg wire.Message) *wire.Message {
+		var ack messages.PassphraseAck
+		if err := proto.Unmarshal(msg.Data, &ack); err != nil {
+			return failure(messages.FailureType_Failure_DataError, err.Error())
+		}
+		s.passphraseCached = true
+		return next()
+	})
+	return reply(messages.MessageType_MessageType_PassphraseRequest, &messages.PassphraseRequest{})
+}
+
+func (s *Simulator) requireInitialized() *wire.Message {
+	if s.mnemonic == "" {
+		return failure(messages.FailureType_Failure_NotInitialized, "Device not initialized")
+	}
+	return nil
+}
+
+func (s *Simulator) requireNotInitialized() *wire.Message {
+	if s.mnemonic != "" {
+		return failure(messages.FailureType_Failure_UnexpectedMessage, "Device is already initialized. Use Wipe first.")
+	}
+	return nil
+}
+
+// maxAddresses is the number of addresses derived by the firmware, the addresses indexes are below it
+const maxAddresses = 100
+
+// secKeys derives the first n secret keys of the stored mnemonic
+func (s *Simulator) secKeys(n int) ([]cipher.SecKey, error) {
+	return cipher.GenerateDeterministicKeyPairs([]byte(s.mnemonic), n)
+}
+
+func (s *Simulator) ping(msg wire.Message) *wire.Message {
+	var ping messages.Ping
+	if err := proto.Unmarshal(msg.Data, &ping); err != nil {
+		return failure(messages.FailureType_Failure_DataError, err.Error())
+	}
+	return success(ping.GetMessage())
+}
+
+func (s *Simulator) setMnemonic(msg wire.Message) *wire.Message {
+	var req messages.SetMnemonic
+	if err := proto.Unmarshal(msg.Data, &req); err != nil {
+		return failure(messages.FailureType_Failure_DataError, err.Error())
+	}
+	if f := s.requireNotInitialized(); f != nil {
+		return f
+	}
+	if strings.TrimSpace(req.GetMnemonic()) == "" {
+		return failure(messages.FailureType_Failure_DataError, "Mnemonic not valid")
+	}
+
+	return s.button(messages.ButtonRequestType_ButtonRequest_ProtectCall, func() *wire.Message {
+		s.mnemonic = req.GetMnemonic()
+		s.needsBackup = false
+		return success("Mnemonic successfully configured")
+	})
+}
+
+func (s *Simulator) generateMnemonic(msg wire.Message) *wire.Message {
+	var req messages.GenerateMnemonic
+	if err := proto.Unmarshal(msg.Data, &req); err != nil {
+		return failure(messages.FailureType_Failure_DataError, err.Error())
+	}
+	if f := s.requireNotInitialized(); f != nil {
+		return f
+	}
+
+	wordCount := req.GetWordCount()
+	if wordCount == 0 {
+		wordCount = 12
+	}
+	if wordCount != 12 && wordCount != 24 {
+		return failure(messages.FailureType_Failure_DataError, "Invalid word count (has to be 12 or 24 bits)")
+	}
+
+	if s.entropy == nil {
+		s.expect(messages.MessageType_MessageType_EntropyAck, func(msg wire.Message) *wire.Message {
+			var ack messages.EntropyAck
+			if err := proto.Unmarshal(msg.Data, &ack); err != nil {
+				return failure(messages.FailureType_Failure_DataError, err.Error())
+			}
+			s.entropy = ack.Entropy
+			return success("Entropy applied")
+		})
+		return reply(messages.MessageType_MessageType_EntropyRequest, &messages.EntropyRequest{})
+	}
+
+	internal := make([]byte, 32)
+	s.rand.Read(internal) // nolint: gosec
+	seed := sha256.Sum256(append(internal, s.entropy...))
+	s.entropy = nil
+
+	// each word takes 6 bits of the seed, extend it by hashing when 24 words are needed
+	bits := seed[:]
+	for len(bits)*8 < int(wordCount)*6 {
+		next := sha256.Sum256(bits)
+		bits = append(bits, next[:]...)
+	}
+	words := make([]string, wordCount)
+	for i := range words {
+		bit := i * 6
+		v := binary.BigEndian.Uint16(bits[bit/8:]) >> (10 - uint(bit%8))
+		words[i] = wordlist[v&0x3f]
+	}
+
+	s.mnemonic = strings.Join(words, " ")
+	s.passphraseProtection = req.GetPassphraseProtection()
+	s.needsBackup = true
+	return success("Mnemonic successfully configured")
+}
+
+func (s *Simulator) skycoinAddress(msg wire.Message) *wire.Message {
+	var req messages.SkycoinAddress
+	if err := proto.Unmarshal(msg.Data, &req); err != nil {
+		return failure(messages.FailureType_Failure_DataError, err.Error())
+	}
+	if f := s.requireInitialized(); f != nil {
+		return f
+	}
+	if req.GetAddressN() == 0 || uint64(req.GetAddressN())+uint64(req.GetStartIndex()) > maxAddresses {
+		return failure(messages.FailureType_Failure_DataError, "Asking for too much addresses")
+	}
+
+	return s.requirePin(func() *wire.Message {
+		return s.requirePassphrase(func() *wire.Message {
+			keys, err := s.secKeys(int(req.GetStartIndex() + req.GetAddressN()))
+			if err != nil {
+				return failure(messages.FailureType_Failure_ProcessError, err.Error())
+			}
+			addresses := make([]string, 0, req.GetAddressN())
+			for _, key := range keys[req.GetStartIndex():] {
+				addresses = append(addresses, cipher.MustAddressFromSecKey(key).String())
+			}
+
+			response := func() *wire.Message {
+				return reply(messages.MessageType_MessageType_ResponseSkycoinAddress, &messages.ResponseSkycoinAddress{
+					Addresses: addresses,
+				})
+			}
+			if req.GetConfirmAddress() && req.GetAddressN() == 1 {
+				return s.button(messages.ButtonRequestType_ButtonRequest_Address, response)
+			}
+			return response()
+		})
+	})
+}
+
+// messageHash returns the digest signed for a message, a 64 characters hex message is already a digest
+func messageHash(message string) cipher.SHA256 {
+	if len(message) == 64 {
+		if h, err := cipher.SHA256FromHex(message); err == nil {
+			return h
+		}
+	}
+	return cipher.SumSHA256([]byte(message))
+}
+
+func (s *Simulator) signMessage(msg wire.Message) *wire.Message {
+	var req messages.SkycoinSignMessage
+	if err := proto.Unmarshal(msg.Data, &req); err != nil {
+		return failure(messages.FailureType_Failure_DataError, err.Error())
+	}
+	if f := s.requireInitialized(); f != nil {
+		return f
+	}
+
+	if req.GetAddressN() >= maxAddresses {
+		return failure(messages.FailureType_Failure_DataError, "Address index out of range")
+	}
+
+	return s.requirePin(func() *wire.Message {
+		return s.requirePassphrase(func() *wire.Message {
+			keys, err := s.secKeys(int(req.GetAddressN()) + 1)
+			if err != nil {
+				return failure(messages.FailureType_Failure_ProcessError, err.Error())
+			}
+			sig, err := cipher.SignHash(messageHash(req.GetMessage()), keys[req.GetAddressN()])
+			if err != nil {
+				return failure(messages.FailureType_Failure_ProcessError, err.Error())
+			}
+			return reply(messages.MessageType_MessageType_ResponseSkycoinSignMessage, &messages.ResponseSkycoinSignMessage{
+				SignedMessage: proto.String(sig.Hex()),
+			})
+		})
+	})
+}
+
+func (s *Simulator) checkMessageSignature(msg wire.Message) *wire.Message {
+	var req messages.SkycoinCheckMessageSignature
+	if err := proto.Unmarshal(msg.Data, &req); err != nil {
+		return failure(messages.FailureType_Failure_DataError, err.Error())
+	}
+
+	sig, err := cipher.SigFromHex(req.GetSignature())
+	if err != nil {
+		return failure(messages.FailureType_Failure_InvalidSignature, "Invalid signature")
+	}
+	pubKey, err := cipher.PubKeyFromSig(sig, messageHash(req.GetMessage()))
+	if err != nil {
+		return failure(messages.FailureType_Failure_InvalidSignature, "Invalid signature")
+	}
+	address := cipher.AddressFromPubKey(pubKey).String()
+	if address != req.GetAddress() {
+		return failure(messages.FailureType_Failure_InvalidSignature, "Address does not match")
+	}
+	return success(address)
+}
+
+// transactionInnerHash computes the inner hash of a skycoin transaction from the device inputs and outputs
+func transactionInnerHash(inputs []*messages.SkycoinTransactionInput, outputs []*messages.SkycoinTransactionOutput) (cipher.SHA256, error) {
+	var b bytes.Buffer
+	var n [8]byte
+
+	binary.LittleEndian.PutUint32(n[:4], uint32(len(inputs)))
+	b.Write(n[:4])
+	for _, in := range inputs {
+		h, err := cipher.SHA256FromHex(in.GetHashIn())
+		if err != nil {
+			return cipher.SHA256{}, fmt.Errorf("invalid input hash %q: %v", in.GetHashIn(), err)
+		}
+		b.Write(h[:])
+	}
+
+	binary.LittleEndian.PutUint32(n[:4], uint32(len(outputs)))
+	b.Write(n[:4])
+	for _, out := range outputs {
+		addr, err := cipher.DecodeBase58Address(out.GetAddress())
+		if err != nil {
+			return cipher.SHA256{}, fmt.Errorf("invalid output address %q: %v", out.GetAddress(), err)
+		}
+		b.WriteByte(addr.Version)
+		b.Write(addr.Key[:])
+		binary.LittleEndian.PutUint64(n[:], out.GetCoin())
+		b.Write(n[:])
+		binary.LittleEndian.PutUint64(n[:], out.GetHour())
+		b.Write(n[:])
+	}
+
+	return cipher.SumSHA256(b.Bytes()), nil
+}
+
+func (s *Simulator) transactionSign(msg wire.Message) *wire.Message {
+	var req messages.TransactionSign
+	if err := proto.Unmarshal(msg.Data, &req); err != nil {
+		return failure(messages.FailureType_Failure_DataError, err.Error())
+	}
+	if f := s.requireInitialized(); f != nil {
+		return f
+	}
+	if len(req.TransactionIn) == 0 || len(req.TransactionOut) == 0 {
+		return failure(messages.FailureType_Failure_DataError, "Transaction must have inputs and outputs")
+	}
+
+	for _, in := range req.TransactionIn {
+		if in.GetIndex() >= maxAddresses {
+			return failure(messages.FailureType_Failure_DataError, "Address index out of range")
+		}
+	}
+
+	innerHash, err := transactionInnerHash(req.TransactionIn, req.TransactionOut)
+	if err != nil {
+		return failure(messages.FailureType_Failure_DataError, err.Error())
+	}
+
+	return s.requirePin(func() *wire.Message {
+		return s.requirePassphrase(func() *wire.Message {
+			return s.button(messages.ButtonRequestType_ButtonRequest_SignTx, func() *wire.Message {
+				var maxIndex uint32
+				for _, in := range req.TransactionIn {
+					if in.GetIndex() > maxIndex {
+						maxIndex = in.GetIndex()
+					}
+				}
+				keys, err := s.secKeys(int(maxIndex) + 1)
+				if err != nil {
+					return failure(messages.FailureType_Failure_ProcessError, err.Error())
+				}
+
+				signatures := make([]string, len(req.TransactionIn))
+				for i, in := range req.TransactionIn {
+					// validated when computing the inner hash
+					h := cipher.MustSHA256FromHex(in.GetHashIn())
+					sig, err := cipher.SignHash(cipher.AddSHA256(innerHash, h), keys[in.GetIndex()])
+					if err != nil {
+						return failure(messages.FailureType_Failure_ProcessError, err.Error())
+					}
+					signatures[i] = sig.Hex()
+				}
+				return reply(messages.MessageType_MessageType_ResponseTransactionSign, &messages.ResponseTransactionSign{
+					Signatures: signatures,
+				})
+			})
+		})
+	})
+}
+
+func (s *Simulator) changePin(msg wire.Message) *wire.Message {
+	var req messages.ChangePin
+	if err := proto.Unmarshal(msg.Data, &req); err != nil {
+		return failure(messages.FailureType_Failure_DataError, err.Error())
+	}
+
+	setPin := func() *wire.Message {
+		if req.GetRemove() {
+			s.pin = ""
+			s.pinCached = false
+			return success("PIN removed")
+		}
+		return s.requestPin(messages.PinMatrixRequestType_PinMatrixRequestType_NewFirst, func(first string) *wire.Message {
+			return s.requestPin(messages.PinMatrixRequestType_PinMatrixRequestType_NewSecond, func(second string) *wire.Message {
+				if first != second {
+					return failure(messages.FailureType_Failure_PinMismatch, "PIN mismatch")
+				}
+				s.pin = first
+				s.pinCached = true
+				return success("PIN changed")
+			})
+		})
+	}
+
+	return s.button(messages.ButtonRequestType_ButtonRequest_ProtectCall, func() *wire.Message {
+		if s.pin == "" {
+			return setPin()
+		}
+		return s.requestPin(messages.PinMatrixRequestType_PinMatrixRequestType_Current, func(pin string) *wire.Message {
+			if pin != s.pin {
+				return failure(messages.FailureType_Failure_PinInvalid, "PIN invalid")
+			}
+			return setPin()
+		})
+	})
+}
+
+func (s *Simulator) wipe() *wire.Message {
+	return s.button(messages.ButtonRequestType_ButtonRequest_WipeDevice, func() *wire.Message {
+		s.label = ""
+		s.mnemonic = ""
+		s.pin = ""
+		s.passphraseProtection = false
+		s.needsBackup = false
+		s.pinCached = false
+		s.passphraseCached = false
+		s.deviceID = s.randomHex(12)
+		return success("Device wiped")
+	})
+}
+
+func (s *Simulator) backup() *wire.Message {
+	if f := s.requireInitialized(); f != nil {
+		return f
+	}
+	if !s.needsBackup {
+		return failure(messages.FailureType_Failure_UnexpectedMessage, "Seed already backed up")
+	}
+
+	words := strings.Fields(s.mnemonic)
+	var confirm func(i int) *wire.Message
+	confirm = func(i int) *wire.Message {
+		if i == len(words) {
+			s.resetWord = ""
+			s.needsBackup = false
+			return success("Device backed up!")
+		}
+		s.resetWord = words[i]
+		return s.button(messages.ButtonRequestType_ButtonRequest_ConfirmWord, func() *wire.Message {
+			return confirm(i + 1)
+		})
+	}
+
+	return s.requirePin(func() *wire.Message {
+		return confirm(0)
+	})
+}
+
+func (s *Simulator) recovery(msg wire.Message) *wire.Message {
+	var req messages.RecoveryDevice
+	if err := proto.Unmarshal(msg.Data, &req); err != nil {
+		return failure(messages.FailureType_Failure_DataError, err.Error())
+	}
+	dryRun := req.GetDryRun()
+	if dryRun {
+		if f := s.requireInitialized(); f != nil {
+			return f
+		}
+	} else if f := s.requireNotInitialized(); f != nil {
+		return f
+	}
+
+	wordCount := req.GetWordCount()
+	if wordCount == 0 {
+		wordCount = 12
+	}
+	if wordCount != 12 && wordCount != 24 {
+		return failure(messages.FailureType_Failure_DataError, "Invalid word count (has to be 12 or 24 bits)")
+	}
+
+	words := make([]string, 0, wordCount)
+	var askWord func() *wire.Message
+	askWord = func() *wire.Message {
+		if len(words) == int(wordCount) {
+			s.recoveryWordPos = 0
+			mnemonic := strings.Join(words, " ")
+			if dryRun {
+				if mnemonic != s.mnemonic {
+					return failure(messages.FailureType_Failure_DataError, "The seed is valid but does not match the one in the device")
+				}
+				return success("The seed is valid and matches the one in the device")
+			}
+			s.mnemonic = mnemonic
+			s.passphraseProtection = req.GetPassphraseProtection()
+			s.needsBackup = false
+			return success("Device recovered")
+		}
+		// the words are asked in order
+		s.recoveryWordPos = uint32(len(words) + 1)
+		s.expect(messages.MessageType_MessageType_WordAck, func(msg wire.Message) *wire.Message {
+			var ack messages.WordAck
+			if err := proto.Unmarshal(msg.Data, &ack); err != nil {
+				return failure(messages.FailureType_Failure_DataError, err.Error())
+			}
+			words = append(words, strings.TrimSpace(ack.GetWord()))
+			return askWord()
+		})
+		return reply(messages.MessageType_MessageType_WordRequest, &messages.WordRequest{})
+	}
+
+	return s.button(messages.ButtonRequestType_ButtonRequest_ProtectCall, askWord)
+}
+
+func (s *Simulator) applySettings(msg wire.Message) *wire.Message {
+	var req messages.ApplySettings
+	if err := proto.Unmarshal(msg.Data, &req); err != nil {
+		return failure(messages.FailureType_Failure_DataError, err.Error())
+	}
+	if req.Label == nil && req.UsePassphrase == nil {
+		return failure(messages.FailureType_Failure_DataError, "No setting provided")
+	}
+
+	return s.requirePin(func() *wire.Message {
+		return s.button(messages.ButtonRequestType_ButtonRequest_ProtectCall, func() *wire.Message {
+			if req.Label != nil {
+				s.label = req.GetLabel()
+			}
+			if req.UsePassphrase != nil {
+				s.passphraseProtection = req.GetUsePassphrase()
+				s.passphraseCached = false
+			}
+			return success("Settings applied")
+		})
+	})
+}
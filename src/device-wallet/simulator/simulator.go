@@ -0,0 +1,295 @@
+/*
+Package simulator implements an in-memory skywallet device.
+
+The simulator speaks the same report based protocol as the firmware, so it can
+be plugged into a devicewallet.Device through its Driver and exercised without
+any hardware or emulator binary. Its state is deterministic for a given Config.
+*/
+package simulator
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+var (
+	// ErrClosed is returned when reading or writing on a closed connection
+	ErrClosed = errors.New("simulator: connection closed")
+
+	// buttonPressPrefix is the prefix of the emulator button press packet,
+	// see devicewallet.MessageSimulateButtonPress
+	buttonPressPrefix = []byte{0, 1, 2, 3, 4}
+)
+
+// Config holds the initial state of a simulated device
+type Config struct {
+	// Label reported in Features
+	Label string
+	// MajorVersion, MinorVersion and PatchVersion are the firmware version reported in Features
+	MajorVersion uint32
+	MinorVersion uint32
+	PatchVersion uint32
+	// Seed makes the device id, the PIN matrix layout and the generated mnemonics reproducible
+	Seed int64
+	// AutoPress confirms button requests when the host reads without pressing a button
+	AutoPress bool
+	// Bootloader starts the device in bootloader mode, see Simulator.SetBootloader
+	Bootloader bool
+}
+
+// DefaultConfig returns the configuration used by New
+func DefaultConfig() Config {
+	return Config{
+		MajorVersion: 1,
+		MinorVersion: 7,
+		PatchVersion: 0,
+		Seed:         1,
+		AutoPress:    true,
+	}
+}
+
+// prompt is an interaction the device is waiting for the host to answer
+type prompt struct {
+	expect messages.MessageType
+	next   func(msg wire.Message) *wire.Message
+}
+
+// Simulator is an in-memory skywallet device
+type Simulator struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	config Config
+	rand   *rand.Rand
+
+	// storage
+	deviceID             string
+	label                string
+	mnemonic             string
+	pin                  string
+	passphraseProtection bool
+	needsBackup          bool
+
+	// session
+	pinCached        bool
+	passphraseCached bool
+	entropy          []byte
+	matrix           string
+
+	// ongoing operation
+	pending *prompt
+	press   func(confirmed bool) *wire.Message
+	// decisions sent through the DebugLink before the button request was acknowledged
+	decisions []bool
+	// resetWord is the word displayed during the backup, recoveryWordPos the position of the word asked during the recovery
+	resetWord       string
+	recoveryWordPos uint32
+
+	// bootloader mode, firmwareErased is set once FirmwareErase is received
+	bootloader     bool
+	firmwareErased bool
+
+	// transport
+	in  *wire.Decoder
+	out [][wire.ReportSize]byte
+}
+
+// New creates a simulated device with the default configuration
+func New() *Simulator {
+	return NewWithConfig(DefaultConfig())
+}
+
+// NewWithConfig creates a simulated device with the given configuration
+func NewWithConfig(config Config) *Simulator {
+	s := &Simulator{
+		config: config,
+		rand:   rand.New(rand.NewSource(config.Seed)), // nolint: gosec
+		label:  config.Label,
+
+		bootloader: config.Bootloader,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	s.in = wire.NewDecoder(nil, wire.ReportSize)
+	s.deviceID = s.randomHex(12)
+	return s
+}
+
+// Open returns a new connection to the simulated device.
+// All the connections share the device state, like several handles on the same usb device.
+// A new connection starts with empty report queues, the reports of a message partially
+// exchanged on a previous connection are dropped.
+func (s *Simulator) Open() io.ReadWriteCloser {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.in.Reset()
+	s.out = nil
+	return &conn{sim: s}
+}
+
+// Mnemonic returns the mnemonic stored in the device
+func (s *Simulator) Mnemonic() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mnemonic
+}
+
+// Matrix returns the layout of the PIN matrix currently displayed by the device.
+// The digit at position i is the one selected by sending the character '1'+i.
+func (s *Simulator) Matrix() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.matrix
+}
+
+// PressButton simulates a button press on the device
+func (s *Simulator) PressButton(confirm bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pressLocked(confirm)
+}
+
+func (s *Simulator) pressLocked(confirm bool) {
+	if s.press == nil {
+		return
+	}
+	press := s.press
+	s.press = nil
+	s.enqueue(press(confirm))
+}
+
+// write handles a report sent by the host
+func (s *Simulator) write(report []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if bytes.HasPrefix(report, buttonPressPrefix) && len(report) > len(buttonPressPrefix) {
+		// emulator button press packet, left button cancels
+		s.pressLocked(report[len(buttonPressPrefix)] != 0)
+		return nil
+	}
+
+	msg, err := s.in.Feed(report)
+	if err != nil || msg == nil {
+		return err
+	}
+	s.enqueue(s.handle(*msg))
+	return nil
+}
+
+// read returns the next report sent by the device, blocking until one is available
+func (s *Simulator) read(c *conn, buf []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.out) == 0 {
+		if c.closed {
+			return 0, ErrClosed
+		}
+		if s.press != nil && s.config.AutoPress {
+			s.pressLocked(true)
+			continue
+		}
+		s.cond.Wait()
+	}
+
+	report := s.out[0]
+	s.out = s.out[1:]
+	return copy(buf, report[:]), nil
+}
+
+type reportWriter struct {
+	reports *[][wire.ReportSize]byte
+}
+
+func (w reportWriter) Write(p []byte) (int, error) {
+	var report [wire.ReportSize]byte
+	n := copy(report[:], p)
+	*w.reports = append(*w.reports, report)
+	return n, nil
+}
+
+func (s *Simulator) enqueue(msg *wire.Message) {
+	if msg == nil {
+		return
+	}
+	if _, err := msg.WriteTo(reportWriter{&s.out}); err != nil {
+		// writing to memory can not fail
+		panic(err)
+	}
+	s.cond.Broadcast()
+}
+
+func (s *Simulator) randomHex(n int) string {
+	const digits = "0123456789ABCDEF"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = digits[s.rand.Intn(len(digits))]
+	}
+	return string(b)
+}
+
+// reply encodes a message sent by the device
+func reply(kind messages.MessageType, pb proto.Message) *wire.Message {
+	data, err := proto.Marshal(pb)
+	if err != nil {
+		// messages built by the simulator are always valid
+		panic(err)
+	}
+	return &wire.Message{
+		Kind: uint16(kind),
+		Data: data,
+	}
+}
+
+func success(message string) *wire.Message {
+	return reply(messages.MessageType_MessageType_Success, &messages.Success{
+		Message: proto.String(message),
+	})
+}
+
+func failure(code messages.FailureType, message string) *wire.Message {
+	return reply(messages.MessageType_MessageType_Failure, &messages.Failure{
+		Code:    code.Enum(),
+		Message: proto.String(message),
+	})
+}
+
+// conn is a connection to the simulated device
+type conn struct {
+	sim    *Simulator
+	closed bool // guarded by sim.mu
+}
+
+func (c *conn) Write(p []byte) (int, error) {
+	c.sim.mu.Lock()
+	closed := c.closed
+	c.sim.mu.Unlock()
+	if closed {
+		return 0, ErrClosed
+	}
+
+	if err := c.sim.write(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *conn) Read(p []byte) (int, error) {
+	return c.sim.read(c, p)
+}
+
+func (c *conn) Close() error {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+	c.closed = true
+	c.sim.cond.Broadcast()
+	return nil
+}
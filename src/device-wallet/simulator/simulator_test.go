@@ -0,0 +1,462 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	devicewallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+const testMnemonic = "cloud flower upset remain green metal below cup stem infant art thank"
+
+// encodePin returns the matrix positions of pin on the matrix currently shown by sim
+func encodePin(t *testing.T, sim *Simulator, pin string) string {
+	matrix := sim.Matrix()
+	require.Len(t, matrix, 9)
+	var positions []byte
+	for _, d := range pin {
+		i := strings.IndexRune(matrix, d)
+		require.True(t, i >= 0)
+		positions = append(positions, byte('1'+i))
+	}
+	return string(positions)
+}
+
+func requireKind(t *testing.T, kind messages.MessageType, msg wire.Message) {
+	require.Equal(t, kind.String(), messages.MessageType(msg.Kind).String())
+}
+
+func TestSetMnemonicAndAddressGen(t *testing.T) {
+	sim := New()
+	device := NewDevice(sim)
+
+	msg, err := device.AddressGen(1, 0, false)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Failure, msg)
+
+	msg, err = device.SetMnemonic(testMnemonic)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Success, msg)
+	require.Equal(t, testMnemonic, sim.Mnemonic())
+
+	msg, err = device.AddressGen(2, 0, false)
+	require.NoError(t, err)
+	addresses, err := devicewallet.DecodeResponseSkycoinAddress(msg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw", "zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs"}, addresses)
+
+	msg, err = device.SignMessage(1, "Hello World!")
+	require.NoError(t, err)
+	signature, err := devicewallet.DecodeResponseSkycoinSignMessage(msg)
+	require.NoError(t, err)
+	require.Len(t, signature, 130)
+
+	msg, err = device.CheckMessageSignature("Hello World!", signature, addresses[1])
+	require.NoError(t, err)
+	resp, err := devicewallet.DecodeSuccessMsg(msg)
+	require.NoError(t, err)
+	require.Equal(t, addresses[1], resp)
+
+	msg, err = device.CheckMessageSignature("Hello World!", signature, addresses[0])
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Failure, msg)
+}
+
+func TestChangePinAndUnlock(t *testing.T) {
+	sim := New()
+	device := NewDevice(sim)
+
+	_, err := device.SetMnemonic(testMnemonic)
+	require.NoError(t, err)
+
+	msg, err := device.ChangePin()
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_PinMatrixRequest, msg)
+
+	msg, err = device.PinMatrixAck(encodePin(t, sim, "1234"))
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_PinMatrixRequest, msg)
+
+	msg, err = device.PinMatrixAck(encodePin(t, sim, "1234"))
+	require.NoError(t, err)
+	resp, err := devicewallet.DecodeSuccessMsg(msg)
+	require.NoError(t, err)
+	require.Equal(t, "PIN changed", resp)
+
+	msg, err = device.GetFeatures()
+	require.NoError(t, err)
+	var features messages.Features
+	require.NoError(t, proto.Unmarshal(msg.Data, &features))
+	require.True(t, features.GetPinProtection())
+	require.True(t, features.GetPinCached())
+}
+
+func TestWrongPin(t *testing.T) {
+	sim := New()
+	device := NewDevice(sim)
+
+	_, err := device.SetMnemonic(testMnemonic)
+	require.NoError(t, err)
+	_, err = device.ChangePin()
+	require.NoError(t, err)
+	_, err = device.PinMatrixAck(encodePin(t, sim, "1234"))
+	require.NoError(t, err)
+	_, err = device.PinMatrixAck(encodePin(t, sim, "1234"))
+	require.NoError(t, err)
+
+	// Backup initializes the device, which clears the cached PIN
+	_, err = device.Backup()
+	require.NoError(t, err)
+
+	msg, err := device.AddressGen(1, 0, false)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_PinMatrixRequest, msg)
+
+	msg, err = device.PinMatrixAck(encodePin(t, sim, "4321"))
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Failure, msg)
+
+	msg, err = device.AddressGen(1, 0, false)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_PinMatrixRequest, msg)
+
+	msg, err = device.PinMatrixAck(encodePin(t, sim, "1234"))
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_ResponseSkycoinAddress, msg)
+}
+
+func TestCancelButton(t *testing.T) {
+	sim := New()
+	device := NewDevice(sim)
+	require.NoError(t, device.SetAutoPressButton(true, devicewallet.ButtonLeft))
+
+	msg, err := device.SetMnemonic(testMnemonic)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Failure, msg)
+	require.Empty(t, sim.Mnemonic())
+}
+
+func TestGenerateMnemonicAndBackup(t *testing.T) {
+	sim := New()
+	device := NewDevice(sim)
+
+	msg, err := device.GenerateMnemonic(12, false)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Success, msg)
+	require.Len(t, strings.Fields(sim.Mnemonic()), 12)
+
+	msg, err = device.GetFeatures()
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Features, msg)
+
+	msg, err = device.Backup()
+	require.NoError(t, err)
+	resp, err := devicewallet.DecodeSuccessMsg(msg)
+	require.NoError(t, err)
+	require.Equal(t, "Device backed up!", resp)
+}
+
+func TestRecovery(t *testing.T) {
+	sim := New()
+	device := NewDevice(sim)
+
+	msg, err := device.Recovery(12, false, false)
+	require.NoError(t, err)
+	for _, word := range strings.Fields(testMnemonic) {
+		requireKind(t, messages.MessageType_MessageType_WordRequest, msg)
+		msg, err = device.WordAck(word)
+		require.NoError(t, err)
+	}
+	requireKind(t, messages.MessageType_MessageType_Success, msg)
+	require.Equal(t, testMnemonic, sim.Mnemonic())
+}
+
+// pinInteractor answers the device requests using the PIN matrix of the simulator
+type pinInteractor struct {
+	t       *testing.T
+	sim     *Simulator
+	pin     string
+	words   []string
+	buttons []messages.ButtonRequestType
+}
+
+func (pi *pinInteractor) RequestPin(messages.PinMatrixRequestType) (string, error) {
+	if pi.pin == "" {
+		return "", errors.New("no PIN")
+	}
+	return encodePin(pi.t, pi.sim, pi.pin), nil
+}
+
+func (pi *pinInteractor) RequestPassphrase() (string, error) {
+	return "", nil
+}
+
+func (pi *pinInteractor) RequestWord() (string, error) {
+	word := pi.words[0]
+	pi.words = pi.words[1:]
+	return word, nil
+}
+
+func (pi *pinInteractor) OnButton(code messages.ButtonRequestType) error {
+	pi.buttons = append(pi.buttons, code)
+	return nil
+}
+
+func TestInteractor(t *testing.T) {
+	sim := New()
+	device := NewDevice(sim)
+	interactor := &pinInteractor{t: t, sim: sim, words: strings.Fields(testMnemonic)}
+	device.SetInteractor(interactor)
+
+	msg, err := device.Recovery(12, false, false)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Success, msg)
+	require.Equal(t, testMnemonic, sim.Mnemonic())
+	require.Empty(t, interactor.words)
+
+	interactor.pin = "1234"
+	msg, err = device.ChangePin()
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Success, msg)
+	require.Equal(t, []messages.ButtonRequestType{
+		messages.ButtonRequestType_ButtonRequest_ProtectCall,
+		messages.ButtonRequestType_ButtonRequest_ProtectCall,
+	}, interactor.buttons)
+
+	// Backup initializes the device, the PIN is requested again
+	_, err = device.Backup()
+	require.NoError(t, err)
+	msg, err = device.AddressGen(1, 0, false)
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_ResponseSkycoinAddress, msg)
+
+	// the operation is cancelled when the interactor fails
+	_, err = device.Backup()
+	require.NoError(t, err)
+	interactor.pin = ""
+	_, err = device.AddressGen(1, 0, false)
+	require.EqualError(t, err, "no PIN")
+	msg, err = device.GetFeatures()
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Features, msg)
+}
+
+func TestContextCancel(t *testing.T) {
+	config := DefaultConfig()
+	config.AutoPress = false
+	sim := NewWithConfig(config)
+	device := NewDevice(sim)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// nobody presses the button
+	_, err := device.SetMnemonicContext(ctx, testMnemonic)
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Empty(t, sim.Mnemonic())
+
+	// the Cancel answer was consumed, the device is ready for the next operation
+	msg, err := device.GetFeaturesContext(context.Background())
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Features, msg)
+}
+
+// blockingInteractor waits for release to answer the device requests
+type blockingInteractor struct {
+	release chan struct{}
+}
+
+func (bi *blockingInteractor) RequestPin(messages.PinMatrixRequestType) (string, error) {
+	<-bi.release
+	return "", errors.New("released")
+}
+
+func (bi *blockingInteractor) RequestPassphrase() (string, error) {
+	<-bi.release
+	return "", errors.New("released")
+}
+
+func (bi *blockingInteractor) RequestWord() (string, error) {
+	<-bi.release
+	return "", errors.New("released")
+}
+
+func (bi *blockingInteractor) OnButton(messages.ButtonRequestType) error {
+	<-bi.release
+	return errors.New("released")
+}
+
+func TestContextCancelInteractor(t *testing.T) {
+	sim := New()
+	device := NewDevice(sim)
+	interactor := &blockingInteractor{release: make(chan struct{})}
+	defer close(interactor.release)
+	device.SetInteractor(interactor)
+
+	// the operation does not wait for the user once the deadline expires
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := device.SetMnemonicContext(ctx, testMnemonic)
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.True(t, time.Since(start) < time.Second, "%s", time.Since(start))
+	require.Empty(t, sim.Mnemonic())
+
+	device.SetInteractor(nil)
+	msg, err := device.GetFeaturesContext(context.Background())
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Features, msg)
+}
+
+// slowDriver takes delay to open a connection to the simulated device
+type slowDriver struct {
+	*Driver
+	delay time.Duration
+}
+
+func (drv *slowDriver) GetDevice() (io.ReadWriteCloser, error) {
+	time.Sleep(drv.delay)
+	return drv.Driver.GetDevice()
+}
+
+func TestContextCancelConnecting(t *testing.T) {
+	sim := New()
+	device := &devicewallet.Device{Driver: &slowDriver{Driver: NewDriver(sim), delay: 200 * time.Millisecond}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// the deadline expires before the connection is open, the mnemonic is never sent
+	_, err := device.SetMnemonicContext(ctx, testMnemonic)
+	require.Equal(t, context.DeadlineExceeded, err)
+	time.Sleep(100 * time.Millisecond)
+	require.Empty(t, sim.Mnemonic())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.False(t, device.ConnectedContext(ctx))
+	require.True(t, device.ConnectedContext(context.Background()))
+
+	msg, err := device.GetFeaturesContext(context.Background())
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Features, msg)
+}
+
+// countingDriver counts the connections opened to the simulated device
+type countingDriver struct {
+	*Driver
+	opened int
+}
+
+func (drv *countingDriver) GetDevice() (io.ReadWriteCloser, error) {
+	drv.opened++
+	return drv.Driver.GetDevice()
+}
+
+func TestSession(t *testing.T) {
+	sim := New()
+	driver := &countingDriver{Driver: NewDriver(sim)}
+	device := &devicewallet.Device{Driver: driver}
+	interactor := &pinInteractor{t: t, sim: sim, pin: "1234"}
+	device.SetInteractor(interactor)
+
+	_, err := device.SetMnemonic(testMnemonic)
+	require.NoError(t, err)
+	require.Equal(t, 1, driver.opened)
+
+	// the acks are sent on the connection of the operation
+	msg, err := device.ChangePin()
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Success, msg)
+	require.Equal(t, 2, driver.opened)
+
+	require.NoError(t, device.OpenSession())
+	require.Equal(t, devicewallet.ErrSessionOpen, device.OpenSession())
+	require.Equal(t, 3, driver.opened)
+	for i := 0; i < 3; i++ {
+		msg, err = device.AddressGen(1, i, false)
+		require.NoError(t, err)
+		requireKind(t, messages.MessageType_MessageType_ResponseSkycoinAddress, msg)
+	}
+	msg, err = device.GetFeatures()
+	require.NoError(t, err)
+	requireKind(t, messages.MessageType_MessageType_Features, msg)
+	require.Equal(t, 3, driver.opened)
+	require.NoError(t, device.CloseSession())
+
+	_, err = device.GetFeatures()
+	require.NoError(t, err)
+	require.Equal(t, 4, driver.opened)
+}
+
+func TestCall(t *testing.T) {
+	sim := New()
+	device := NewDevice(sim)
+	device.SetInteractor(&pinInteractor{t: t, sim: sim, pin: "1234"})
+
+	resp, err := device.Call(&messages.SetMnemonic{Mnemonic: proto.String(testMnemonic)})
+	require.NoError(t, err)
+	require.Equal(t, &messages.Success{Message: proto.String("Mnemonic successfully configured")}, resp)
+
+	resp, err = device.Call(&messages.SkycoinAddress{AddressN: proto.Uint32(1)})
+	require.NoError(t, err)
+	require.Equal(t, []string{"2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw"}, resp.(*messages.ResponseSkycoinAddress).GetAddresses())
+
+	// a message without wrapper, the ping is echoed
+	resp, err = device.CallContext(context.Background(), &messages.Ping{Message: proto.String("ping")})
+	require.NoError(t, err)
+	require.Equal(t, "ping", resp.(*messages.Success).GetMessage())
+
+	// the device requests are answered by the interactor
+	resp, err = device.Call(&messages.ChangePin{})
+	require.NoError(t, err)
+	require.IsType(t, &messages.Success{}, resp)
+
+	resp, err = device.Call(&messages.SkycoinCheckMessageSignature{
+		Address:   proto.String("2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw"),
+		Message:   proto.String("Hello World!"),
+		Signature: proto.String(strings.Repeat("00", 65)),
+	})
+	require.Error(t, err)
+	var failure devicewallet.FailureError
+	require.True(t, errors.As(err, &failure))
+	require.Equal(t, failure.Code, resp.(*messages.Failure).GetCode())
+}
+
+func TestAddressIndexOutOfRange(t *testing.T) {
+	device := NewDevice(New())
+	_, err := device.SetMnemonic(testMnemonic)
+	require.NoError(t, err)
+	client := devicewallet.NewClient(device)
+
+	// the start index and the number of addresses do not wrap past the bound
+	_, err = client.AddressGen(2, 0xffffffff, false)
+	require.True(t, errors.Is(err, devicewallet.ErrDataError), "%v", err)
+	_, err = client.AddressGen(1, 100, false)
+	require.True(t, errors.Is(err, devicewallet.ErrDataError), "%v", err)
+	addresses, err := client.AddressGen(1, 99, false)
+	require.NoError(t, err)
+	require.Len(t, addresses, 1)
+
+	_, err = client.SignMessage(4000000000, "Hello World!")
+	require.True(t, errors.Is(err, devicewallet.ErrDataError), "%v", err)
+
+	_, _, err = client.TransactionSign([]*messages.SkycoinTransactionInput{{
+		HashIn: proto.String("181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9"),
+		Index:  proto.Uint32(4000000000),
+	}}, []*messages.SkycoinTransactionOutput{{
+		Address: proto.String("K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot"),
+		Coin:    proto.Uint64(100000),
+		Hour:    proto.Uint64(2),
+	}})
+	require.True(t, errors.Is(err, devicewallet.ErrDataError), "%v", err)
+}
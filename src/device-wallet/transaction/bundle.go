@@ -0,0 +1,393 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+)
+
+const (
+	// BundleType identifies the bundle files
+	BundleType = "skycoin-transaction-bundle"
+	// BundleVersion is the version of the bundle files written
+	BundleVersion = 1
+)
+
+var (
+	// ErrBundle is returned for files which are not a bundle or of an unsupported version
+	ErrBundle = errors.New("invalid transaction bundle")
+	// ErrBundleChecksum is returned when the checksum of a bundle does not match its content, the file was corrupted.
+	// The checksum is not keyed, it does not detect a deliberate modification: the content is checked by Check
+	// and the change outputs by the device, see deviceWallet.Client.VerifyChange.
+	ErrBundleChecksum = errors.New("transaction bundle checksum mismatch")
+	// ErrBundleMismatch is returned when the transaction of a bundle does not match its uxouts or change outputs
+	ErrBundleMismatch = errors.New("transaction bundle mismatch")
+	// ErrNotSigned is returned when finalizing a bundle not signed yet
+	ErrNotSigned = errors.New("transaction not signed")
+)
+
+// Uxout is an unspent output spent by an input of a transaction
+type Uxout struct {
+	Hash    cipher.SHA256
+	Address cipher.Address
+	// Coins in droplets
+	Coins uint64
+	// Hours of the uxout when created, at Time
+	Hours uint64
+	// Time is the time, in unix seconds, of the block creating the uxout
+	Time uint64
+	// AddressIndex is the index of Address in the device wallet
+	AddressIndex uint32
+}
+
+// Bundle carries a transaction between the online machine creating it and the offline machine signing it.
+// It holds the transaction, unsigned then signed, the uxouts spent by its inputs, in the order of the inputs,
+// and the change outputs with the index of their address in the device wallet. The coin hours are validated
+// at HeadTime with BurnFactor, see Validate.
+type Bundle struct {
+	Transaction *Transaction
+	Uxouts      []Uxout
+	// Change maps the position of the change outputs to the index of their address in the device wallet
+	Change map[int]uint32
+	// HeadTime is the time, in unix seconds, of the head block of the blockchain when the bundle was created
+	HeadTime uint64
+	// BurnFactor of the coin hours, DefaultBurnFactor when 0
+	BurnFactor uint32
+}
+
+// NewBundle returns the bundle of the unsigned txn, the uxouts spent by its inputs are given in any order, see Bundle
+func NewBundle(txn *Transaction, uxouts []Uxout, change map[int]uint32, headTime uint64, burnFactor uint32) (*Bundle, error) {
+	if !unsigned(txn) {
+		return nil, ErrAlreadySigned
+	}
+	if len(uxouts) != len(txn.In) {
+		return nil, fmt.Errorf("%w: %d uxouts for %d inputs", ErrBundleMismatch, len(uxouts), len(txn.In))
+	}
+	byHash := make(map[cipher.SHA256]Uxout, len(uxouts))
+	for _, ux := range uxouts {
+		byHash[ux.Hash] = ux
+	}
+	if change == nil {
+		change = make(map[int]uint32)
+	}
+	b := &Bundle{
+		Transaction: txn,
+		Uxouts:      make([]Uxout, len(txn.In)),
+		Change:      change,
+		HeadTime:    headTime,
+		BurnFactor:  burnFactor,
+	}
+	for i, in := range txn.In {
+		ux, ok := byHash[in]
+		if !ok {
+			return nil, fmt.Errorf("%w: no uxout for the input %s", ErrBundleMismatch, in.Hex())
+		}
+		b.Uxouts[i] = ux
+	}
+	if err := b.Check(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// unsigned reports whether no input of txn has a signature
+func unsigned(txn *Transaction) bool {
+	for _, sig := range txn.Sigs {
+		if sig != (cipher.Sig{}) {
+			return false
+		}
+	}
+	return true
+}
+
+// Owners returns the addresses owning the inputs of the transaction
+func (b *Bundle) Owners() Owners {
+	owners := make(Owners, len(b.Uxouts))
+	for _, ux := range b.Uxouts {
+		owners[ux.Hash] = ux.Address
+	}
+	return owners
+}
+
+// ValidateOptions returns the options validating the transaction of the bundle
+func (b *Bundle) ValidateOptions() *ValidateOptions {
+	return &ValidateOptions{
+		Uxouts:     b.Uxouts,
+		HeadTime:   b.HeadTime,
+		BurnFactor: b.BurnFactor,
+	}
+}
+
+// SignOptions returns the options signing the transaction of the bundle, the transaction is validated and
+// the signatures are verified against the uxouts owners
+func (b *Bundle) SignOptions() SignOptions {
+	options := SignOptions{
+		ChangeIndexes: b.Change,
+		Owners:        b.Owners(),
+		Validation:    b.ValidateOptions(),
+	}
+	for _, ux := range b.Uxouts {
+		options.InputIndexes = append(options.InputIndexes, ux.AddressIndex)
+	}
+	return options
+}
+
+// Signed reports whether the transaction of the bundle is signed
+func (b *Bundle) Signed() bool {
+	return b.Transaction.Signed()
+}
+
+// Check verifies the uxouts are the inputs of the transaction, the change outputs exist, the transaction is valid,
+// its output coins being the coins of the uxouts, see Validate, and, once the transaction is signed, its signatures
+// were made by the owners of the uxouts
+func (b *Bundle) Check() error {
+	txn := b.Transaction
+	if txn.InnerHash != txn.HashInner() {
+		return fmt.Errorf("%w: %v", ErrBundleMismatch, ErrInnerHash)
+	}
+	if len(b.Uxouts) != len(txn.In) {
+		return fmt.Errorf("%w: %d uxouts for %d inputs", ErrBundleMismatch, len(b.Uxouts), len(txn.In))
+	}
+
+	for i, ux := range b.Uxouts {
+		if ux.Hash != txn.In[i] {
+			return fmt.Errorf("%w: uxout %d %s is not the input %s", ErrBundleMismatch, i, ux.Hash.Hex(), txn.In[i].Hex())
+		}
+	}
+	for i := range b.Change {
+		if i < 0 || i >= len(txn.Out) {
+			return fmt.Errorf("%w: change output %d out of the %d outputs", ErrBundleMismatch, i, len(txn.Out))
+		}
+	}
+	if err := Validate(txn, b.ValidateOptions()); err != nil {
+		return err
+	}
+
+	if unsigned(txn) {
+		return nil
+	}
+	if !txn.Signed() {
+		return fmt.Errorf("%w: partially signed transaction", ErrBundleMismatch)
+	}
+	if err := VerifySignatures(txn, b.Owners()); err != nil {
+		return fmt.Errorf("%w: %v", ErrBundleMismatch, err)
+	}
+	return nil
+}
+
+// Sign has the device sign the transaction of the bundle, the signatures are verified against the uxouts owners.
+// The report of the outputs is returned as by Sign.
+func (b *Bundle) Sign(device deviceWallet.Devicer) (deviceWallet.ChangeReport, error) {
+	if err := b.Check(); err != nil {
+		return nil, err
+	}
+	signed, report, err := Sign(device, b.Transaction, b.SignOptions())
+	if err != nil {
+		return report, err
+	}
+	b.Transaction = signed
+	return report, nil
+}
+
+// Finalize returns the signed transaction of the bundle, ready to be broadcast
+func (b *Bundle) Finalize() (*Transaction, error) {
+	if !b.Signed() {
+		return nil, ErrNotSigned
+	}
+	if err := b.Check(); err != nil {
+		return nil, err
+	}
+	return b.Transaction, nil
+}
+
+// bundleFile is the JSON encoding of a Bundle
+type bundleFile struct {
+	Type        string         `json:"type"`
+	Version     int            `json:"version"`
+	Transaction string         `json:"transaction"`
+	Uxouts      []bundleUxout  `json:"uxouts"`
+	Change      []bundleChange `json:"change"`
+	HeadTime    uint64         `json:"headTime"`
+	BurnFactor  uint32         `json:"burnFactor"`
+	// Checksum is the hex SHA256 of the encoded bundle without checksum, it detects corrupted files only
+	Checksum string `json:"checksum"`
+}
+
+type bundleUxout struct {
+	Hash         string `json:"hash"`
+	Address      string `json:"address"`
+	Coins        uint64 `json:"coins"`
+	Hours        uint64 `json:"hours"`
+	Time         uint64 `json:"time"`
+	AddressIndex uint32 `json:"addressIndex"`
+}
+
+func (ux bundleUxout) uxout() (Uxout, error) {
+	hash, err := cipher.SHA256FromHex(ux.Hash)
+	if err != nil {
+		return Uxout{}, fmt.Errorf("hash: %v", err)
+	}
+	addr, err := cipher.DecodeBase58Address(ux.Address)
+	if err != nil {
+		return Uxout{}, fmt.Errorf("address: %v", err)
+	}
+	return Uxout{
+		Hash:         hash,
+		Address:      addr,
+		Coins:        ux.Coins,
+		Hours:        ux.Hours,
+		Time:         ux.Time,
+		AddressIndex: ux.AddressIndex,
+	}, nil
+}
+
+// ReadUxouts decodes the JSON list of the uxouts spent by a transaction, e.g.
+//
+//	[{"hash": "181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9",
+//	  "address": "2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw", "coins": 100000, "hours": 10, "time": 1540000000, "addressIndex": 0}]
+//
+// with the coins in droplets, the hours and time of the uxout creation and addressIndex the index of the address in the device wallet
+func ReadUxouts(r io.Reader) ([]Uxout, error) {
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	var list []bundleUxout
+	if err := d.Decode(&list); err != nil {
+		return nil, fmt.Errorf("invalid uxouts: %v", err)
+	}
+	uxouts := make([]Uxout, len(list))
+	for i, ux := range list {
+		var err error
+		if uxouts[i], err = ux.uxout(); err != nil {
+			return nil, fmt.Errorf("invalid uxout %d %v", i, err)
+		}
+	}
+	return uxouts, nil
+}
+
+type bundleChange struct {
+	Output       int    `json:"output"`
+	AddressIndex uint32 `json:"addressIndex"`
+}
+
+func (f *bundleFile) checksum() (string, error) {
+	unsummed := *f
+	unsummed.Checksum = ""
+	data, err := json.Marshal(&unsummed)
+	if err != nil {
+		return "", err
+	}
+	return cipher.SumSHA256(data).Hex(), nil
+}
+
+// Write encodes the bundle in JSON, with its version and checksum
+func (b *Bundle) Write(w io.Writer) error {
+	f := bundleFile{
+		Type:        BundleType,
+		Version:     BundleVersion,
+		Transaction: b.Transaction.SerializeHex(),
+		Uxouts:      make([]bundleUxout, len(b.Uxouts)),
+		Change:      make([]bundleChange, 0, len(b.Change)),
+		HeadTime:    b.HeadTime,
+		BurnFactor:  b.BurnFactor,
+	}
+	for i, ux := range b.Uxouts {
+		f.Uxouts[i] = bundleUxout{
+			Hash:         ux.Hash.Hex(),
+			Address:      ux.Address.String(),
+			Coins:        ux.Coins,
+			Hours:        ux.Hours,
+			Time:         ux.Time,
+			AddressIndex: ux.AddressIndex,
+		}
+	}
+	for output, index := range b.Change {
+		f.Change = append(f.Change, bundleChange{Output: output, AddressIndex: index})
+	}
+	sort.Slice(f.Change, func(i, j int) bool {
+		return f.Change[i].Output < f.Change[j].Output
+	})
+
+	checksum, err := f.checksum()
+	if err != nil {
+		return err
+	}
+	f.Checksum = checksum
+	data, err := json.MarshalIndent(&f, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadBundle decodes a bundle, refusing unknown versions, corrupted bundles and bundles failing Check
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// the type and version tell how to decode the rest of the bundle
+	var header struct {
+		Type    string `json:"type"`
+		Version int    `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBundle, err)
+	}
+	if header.Type != BundleType {
+		return nil, fmt.Errorf("%w: type %q", ErrBundle, header.Type)
+	}
+	if header.Version != BundleVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrBundle, header.Version)
+	}
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	var f bundleFile
+	if err := d.Decode(&f); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBundle, err)
+	}
+	checksum, err := f.checksum()
+	if err != nil {
+		return nil, err
+	}
+	if f.Checksum != checksum {
+		return nil, ErrBundleChecksum
+	}
+
+	txn, err := DeserializeHex(f.Transaction)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBundle, err)
+	}
+	b := &Bundle{
+		Transaction: txn,
+		Uxouts:      make([]Uxout, len(f.Uxouts)),
+		Change:      make(map[int]uint32, len(f.Change)),
+		HeadTime:    f.HeadTime,
+		BurnFactor:  f.BurnFactor,
+	}
+	for i, ux := range f.Uxouts {
+		if b.Uxouts[i], err = ux.uxout(); err != nil {
+			return nil, fmt.Errorf("%w: uxout %d %v", ErrBundle, i, err)
+		}
+	}
+	for _, change := range f.Change {
+		if _, ok := b.Change[change.Output]; ok {
+			return nil, fmt.Errorf("%w: change output %d listed twice", ErrBundle, change.Output)
+		}
+		b.Change[change.Output] = change.AddressIndex
+	}
+
+	if err := b.Check(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
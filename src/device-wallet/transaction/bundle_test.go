@@ -0,0 +1,172 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/simulator"
+)
+
+// testUxouts returns the uxouts spent by testTransaction, owned by the first address of testMnemonic
+func testUxouts() []Uxout {
+	return []Uxout{{
+		Hash:    cipher.MustSHA256FromHex("181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9"),
+		Address: cipher.MustDecodeBase58Address("2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw"),
+		Coins:   100000,
+		Hours:   10,
+	}}
+}
+
+// rewrite writes the bundle and reads it back
+func rewrite(t *testing.T, b *Bundle) *Bundle {
+	var buf bytes.Buffer
+	require.NoError(t, b.Write(&buf))
+	read, err := ReadBundle(&buf)
+	require.NoError(t, err)
+	return read
+}
+
+func TestBundle(t *testing.T) {
+	device := simulator.NewDevice(simulator.New())
+	_, err := device.SetMnemonic(testMnemonic)
+	require.NoError(t, err)
+
+	b, err := NewBundle(testTransaction(), testUxouts(), nil, 0, 0)
+	require.NoError(t, err)
+	require.False(t, b.Signed())
+	_, err = b.Finalize()
+	require.Equal(t, ErrNotSigned, err)
+
+	b = rewrite(t, b)
+	require.Equal(t, testTransaction(), b.Transaction)
+	require.Equal(t, testUxouts(), b.Uxouts)
+
+	report, err := b.Sign(device)
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	require.True(t, b.Signed())
+	b = rewrite(t, b)
+	require.True(t, b.Signed())
+	_, err = b.Sign(device)
+	require.Equal(t, ErrAlreadySigned, err)
+
+	signed, err := b.Finalize()
+	require.NoError(t, err)
+	require.NoError(t, VerifySignatures(signed, Owners{b.Uxouts[0].Hash: b.Uxouts[0].Address}))
+	require.Equal(t, testTransaction().InnerHash, signed.InnerHash)
+
+	// the uxout is not owned by the address of the device at its index
+	uxouts := testUxouts()
+	uxouts[0].AddressIndex = 1
+	b, err = NewBundle(testTransaction(), uxouts, nil, 0, 0)
+	require.NoError(t, err)
+	_, err = b.Sign(device)
+	require.IsType(t, SignaturesError{}, err)
+	require.False(t, b.Signed())
+}
+
+func TestBundleMismatch(t *testing.T) {
+	txn := testTransaction()
+
+	uxouts := testUxouts()
+	uxouts[0].Coins++
+	_, err := NewBundle(txn, uxouts, nil, 0, 0)
+	require.True(t, errors.Is(err, ErrCoinsMismatch), "%v", err)
+
+	uxouts = testUxouts()
+	uxouts[0].Hash = cipher.SumSHA256([]byte("other"))
+	_, err = NewBundle(txn, uxouts, nil, 0, 0)
+	require.True(t, errors.Is(err, ErrBundleMismatch), "%v", err)
+
+	// no coin hour burned
+	uxouts = testUxouts()
+	uxouts[0].Hours = 2
+	_, err = NewBundle(txn, uxouts, nil, 0, 0)
+	require.True(t, errors.Is(err, ErrInsufficientFee), "%v", err)
+	// the uxout hours grew until the head time
+	_, err = NewBundle(txn, uxouts, nil, 3600*100, 0)
+	require.NoError(t, err)
+
+	_, err = NewBundle(txn, nil, nil, 0, 0)
+	require.True(t, errors.Is(err, ErrBundleMismatch), "%v", err)
+
+	_, err = NewBundle(txn, testUxouts(), map[int]uint32{1: 0}, 0, 0)
+	require.True(t, errors.Is(err, ErrBundleMismatch), "%v", err)
+
+	signed := *txn
+	signed.Sigs = []cipher.Sig{{1}}
+	_, err = NewBundle(&signed, testUxouts(), nil, 0, 0)
+	require.Equal(t, ErrAlreadySigned, err)
+
+	// a signature not made by the owner of the uxout
+	b, err := NewBundle(txn, testUxouts(), nil, 0, 0)
+	require.NoError(t, err)
+	pub, sec := cipher.GenerateKeyPair()
+	b.Transaction = &signed
+	signed.Sigs = []cipher.Sig{cipher.MustSignHash(signed.SignatureHash(0), sec)}
+	require.NotEqual(t, cipher.AddressFromPubKey(pub), b.Uxouts[0].Address)
+	err = b.Check()
+	require.True(t, errors.Is(err, ErrBundleMismatch), "%v", err)
+	_, err = b.Finalize()
+	require.True(t, errors.Is(err, ErrBundleMismatch), "%v", err)
+}
+
+func TestReadBundleTampered(t *testing.T) {
+	b, err := NewBundle(testTransaction(), testUxouts(), map[int]uint32{0: 2}, 0, 0)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, b.Write(&buf))
+	data := buf.String()
+
+	read, err := ReadBundle(strings.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, map[int]uint32{0: 2}, read.Change)
+
+	for name, tc := range map[string]struct {
+		old, new string
+		err      error
+	}{
+		"coins":    {`"coins": 100000`, `"coins": 100001`, ErrBundleChecksum},
+		"change":   {`"addressIndex": 2`, `"addressIndex": 3`, ErrBundleChecksum},
+		"checksum": {`"checksum": "`, `"checksum": "00`, ErrBundleChecksum},
+		"version":  {`"version": 1`, `"version": 2`, ErrBundle},
+		"type":     {BundleType, "other", ErrBundle},
+		"field":    {`"version": 1,`, `"version": 1, "extra": 0,`, ErrBundle},
+	} {
+		require.Contains(t, data, tc.old, name)
+		_, err := ReadBundle(strings.NewReader(strings.Replace(data, tc.old, tc.new, 1)))
+		require.True(t, errors.Is(err, tc.err), "%s: %v", name, err)
+	}
+
+	_, err = ReadBundle(strings.NewReader("{"))
+	require.True(t, errors.Is(err, ErrBundle), "%v", err)
+
+	// the checksum only detects corruption, a modified bundle with its checksum updated is refused by Check
+	var f bundleFile
+	require.NoError(t, json.Unmarshal([]byte(data), &f))
+	f.Uxouts[0].Coins++
+	f.Checksum, err = f.checksum()
+	require.NoError(t, err)
+	modified, err := json.Marshal(&f)
+	require.NoError(t, err)
+	_, err = ReadBundle(bytes.NewReader(modified))
+	require.True(t, errors.Is(err, ErrCoinsMismatch), "%v", err)
+}
+
+func TestReadUxouts(t *testing.T) {
+	uxouts, err := ReadUxouts(strings.NewReader(`[{"hash": "181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9",
+		"address": "2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw", "coins": 100000, "hours": 10, "addressIndex": 0}]`))
+	require.NoError(t, err)
+	require.Equal(t, testUxouts(), uxouts)
+
+	_, err = ReadUxouts(strings.NewReader(`[{"hash": "181bd5", "address": "2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw"}]`))
+	require.Error(t, err)
+	_, err = ReadUxouts(strings.NewReader(`[{"hash": "181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9", "address": "2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzx"}]`))
+	require.Error(t, err)
+}
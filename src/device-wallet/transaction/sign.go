@@ -0,0 +1,121 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/skycoin/skycoin/src/cipher"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+)
+
+// ErrAlreadySigned is returned when signing a transaction with a signature
+var ErrAlreadySigned = errors.New("transaction already signed")
+
+// SignOptions tell the device which keys of its wallet sign the inputs and which outputs are change
+type SignOptions struct {
+	// InputIndexes are the indexes, in the device wallet, of the addresses owning the inputs
+	InputIndexes []uint32
+	// ChangeIndexes maps the position of the change outputs to the index of their address in the device wallet
+	ChangeIndexes map[int]uint32
+	// Owners of the inputs, the signatures are verified against them. When nil the owners are the addresses
+	// derived by the device at InputIndexes, see OwnersFromDevice.
+	Owners Owners
+	// Validation gives the uxouts to check the coins and coin hours of the transaction against, see Validate.
+	// When nil only the checks not needing the uxouts run.
+	Validation *ValidateOptions
+}
+
+// Messages returns the inputs and outputs of txn sent to the device with TransactionSign
+func Messages(txn *Transaction, options SignOptions) ([]*messages.SkycoinTransactionInput, []*messages.SkycoinTransactionOutput, error) {
+	if len(options.InputIndexes) != len(txn.In) {
+		return nil, nil, fmt.Errorf("%d input indexes given for %d inputs", len(options.InputIndexes), len(txn.In))
+	}
+	for i := range options.ChangeIndexes {
+		if i < 0 || i >= len(txn.Out) {
+			return nil, nil, fmt.Errorf("change output %d out of the %d outputs", i, len(txn.Out))
+		}
+	}
+
+	inputs := make([]*messages.SkycoinTransactionInput, len(txn.In))
+	for i, in := range txn.In {
+		inputs[i] = &messages.SkycoinTransactionInput{
+			HashIn: proto.String(in.Hex()),
+			Index:  proto.Uint32(options.InputIndexes[i]),
+		}
+	}
+	outputs := make([]*messages.SkycoinTransactionOutput, len(txn.Out))
+	for i, out := range txn.Out {
+		outputs[i] = &messages.SkycoinTransactionOutput{
+			Address: proto.String(out.Address.String()),
+			Coin:    proto.Uint64(out.Coins),
+			Hour:    proto.Uint64(out.Hours),
+		}
+		if index, ok := options.ChangeIndexes[i]; ok {
+			outputs[i].AddressIndex = proto.Uint32(index)
+		}
+	}
+	return inputs, outputs, nil
+}
+
+// Sign has the device sign the inputs of the unsigned txn and returns the signed transaction, with its new hash,
+// and the report of its outputs, see deviceWallet.Client.TransactionSign. The report is returned along with the
+// errors once the change outputs are verified. The transaction is validated before the device is asked to sign,
+// see Validate, and the signatures are verified to be made by the owners of the inputs, see VerifySignatures.
+func Sign(device deviceWallet.Devicer, txn *Transaction, options SignOptions) (*Transaction, deviceWallet.ChangeReport, error) {
+	for _, sig := range txn.Sigs {
+		if sig != (cipher.Sig{}) {
+			return nil, nil, ErrAlreadySigned
+		}
+	}
+	if err := Validate(txn, options.Validation); err != nil {
+		return nil, nil, err
+	}
+	inputs, outputs, err := Messages(txn, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signatures, report, err := deviceWallet.NewClient(device).TransactionSign(inputs, outputs)
+	if err != nil {
+		return nil, report, err
+	}
+	signed, err := AddSignatures(txn, signatures)
+	if err != nil {
+		return nil, report, err
+	}
+
+	owners := options.Owners
+	if owners == nil {
+		if owners, err = OwnersFromDevice(device, txn, options.InputIndexes); err != nil {
+			return nil, report, err
+		}
+	}
+	if err := VerifySignatures(signed, owners); err != nil {
+		return nil, report, err
+	}
+	return signed, report, nil
+}
+
+// AddSignatures returns a copy of txn signed with the hex signatures, one per input in order
+func AddSignatures(txn *Transaction, signatures []string) (*Transaction, error) {
+	if len(signatures) != len(txn.In) {
+		return nil, fmt.Errorf("%d signatures for %d inputs", len(signatures), len(txn.In))
+	}
+
+	signed := *txn
+	signed.In = append([]cipher.SHA256(nil), txn.In...)
+	signed.Out = append([]Output(nil), txn.Out...)
+	signed.Sigs = make([]cipher.Sig, len(signatures))
+	for i, signature := range signatures {
+		sig, err := cipher.SigFromHex(signature)
+		if err != nil {
+			return nil, fmt.Errorf("signature %d: %v", i, err)
+		}
+		signed.Sigs[i] = sig
+	}
+	signed.UpdateHeader()
+	return &signed, nil
+}
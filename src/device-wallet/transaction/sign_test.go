@@ -0,0 +1,104 @@
+package transaction
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/simulator"
+)
+
+const testMnemonic = "cloud flower upset remain green metal below cup stem infant art thank"
+
+func TestSign(t *testing.T) {
+	device := simulator.NewDevice(simulator.New())
+	_, err := device.SetMnemonic(testMnemonic)
+	require.NoError(t, err)
+
+	txn := testTransaction()
+	txn.In = append(txn.In, cipher.MustSHA256FromHex("8c2c97bfd34e0f0f9833b789ce03c2e80ac0b94b9d0b99cee6ea76fb662e8e1c"))
+	txn.Out = append(txn.Out, Output{
+		Address: cipher.MustDecodeBase58Address("zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs"),
+		Coins:   1000,
+	})
+	txn.Sigs = make([]cipher.Sig, len(txn.In))
+	txn.UpdateHeader()
+
+	signed, report, err := Sign(device, txn, SignOptions{
+		InputIndexes:  []uint32{0, 1},
+		ChangeIndexes: map[int]uint32{1: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, report, 2)
+	require.False(t, report[0].Change)
+	require.True(t, report[1].Change)
+	require.Empty(t, report.Mismatches())
+	require.True(t, signed.Signed())
+	require.False(t, txn.Signed())
+	require.Equal(t, txn.InnerHash, signed.InnerHash)
+	require.NotEqual(t, txn.Hash(), signed.Hash())
+
+	owners := []string{"2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw", "zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs"}
+	for i, owner := range owners {
+		addr := cipher.MustDecodeBase58Address(owner)
+		require.NoError(t, cipher.VerifyAddressSignedHash(addr, signed.Sigs[i], signed.SignatureHash(i)))
+	}
+
+	// the signed transaction is ready to be injected
+	decoded, err := DeserializeHex(signed.SerializeHex())
+	require.NoError(t, err)
+	require.Equal(t, signed, decoded)
+
+	_, _, err = Sign(device, signed, SignOptions{InputIndexes: []uint32{0, 1}})
+	require.Equal(t, ErrAlreadySigned, err)
+	_, _, err = Sign(device, txn, SignOptions{InputIndexes: []uint32{0}})
+	require.EqualError(t, err, "1 input indexes given for 2 inputs")
+	_, _, err = Sign(device, txn, SignOptions{InputIndexes: []uint32{0, 1}, ChangeIndexes: map[int]uint32{2: 0}})
+	require.EqualError(t, err, "change output 2 out of the 2 outputs")
+	_, report, err = Sign(device, txn, SignOptions{InputIndexes: []uint32{0, 1}, ChangeIndexes: map[int]uint32{1: 0}})
+	require.True(t, errors.Is(err, deviceWallet.ErrChangeAddress), "%v", err)
+	require.Len(t, report.Mismatches(), 1)
+}
+
+func TestVerifySignatures(t *testing.T) {
+	device := simulator.NewDevice(simulator.New())
+	_, err := device.SetMnemonic(testMnemonic)
+	require.NoError(t, err)
+
+	txn := testTransaction()
+	in := txn.In[0]
+	owner := cipher.MustDecodeBase58Address("2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw")
+	other := cipher.MustDecodeBase58Address("zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs")
+
+	owners, err := OwnersFromDevice(device, txn, []uint32{0})
+	require.NoError(t, err)
+	require.Equal(t, Owners{in: owner}, owners)
+
+	signed, _, err := Sign(device, txn, SignOptions{InputIndexes: []uint32{0}, Owners: owners})
+	require.NoError(t, err)
+	require.NoError(t, VerifySignatures(signed, owners))
+
+	// signed with the wrong address index
+	_, _, err = Sign(device, txn, SignOptions{InputIndexes: []uint32{1}, Owners: owners})
+	require.Equal(t, SignaturesError{{Input: 0, Hash: in, Owner: owner, Signer: other}}, err)
+	require.EqualError(t, err, "invalid transaction signatures: input 0 "+in.Hex()+
+		": signed by zC8GAQGQBfwk7vtTxVoRG7iMperHNuyYPs instead of its owner 2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw")
+
+	// a signature of another transaction
+	tampered := *signed
+	tampered.Out = []Output{{Address: other, Coins: 100000, Hours: 2}}
+	tampered.UpdateHeader()
+	err = VerifySignatures(&tampered, owners)
+	require.IsType(t, SignaturesError{}, err)
+	require.Equal(t, owner, err.(SignaturesError)[0].Owner)
+	require.NotEqual(t, owner, err.(SignaturesError)[0].Signer)
+
+	err = VerifySignatures(signed, Owners{})
+	require.EqualError(t, err, "invalid transaction signatures: input 0 "+in.Hex()+": owner unknown")
+
+	err = VerifySignatures(txn, owners)
+	require.IsType(t, SignaturesError{}, err)
+}
@@ -0,0 +1,441 @@
+package transaction
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+const (
+	// DropletsPerSKY is the number of droplets in one SKY
+	DropletsPerSKY = 1000000
+	// dropletDecimals is the number of decimals of an amount in SKY
+	dropletDecimals = 6
+)
+
+// ErrCoins is returned for amounts of coins not in SKY nor droplets
+var ErrCoins = errors.New("invalid coins")
+
+// ParseCoins parses an amount of coins, in droplets given as an integer, optionally followed by "droplets",
+// or in SKY given as a decimal number followed by "SKY", e.g. "1500000", "1500000 droplets" or "1.5 SKY"
+func ParseCoins(s string) (uint64, error) {
+	amount := strings.TrimSpace(s)
+	lower := strings.ToLower(amount)
+	switch {
+	case strings.HasSuffix(lower, "droplets"):
+		amount = strings.TrimSpace(amount[:len(amount)-len("droplets")])
+	case strings.HasSuffix(lower, "sky"):
+		return parseSKY(s, strings.TrimSpace(amount[:len(amount)-len("sky")]))
+	case strings.Contains(amount, "."):
+		return 0, fmt.Errorf("%w %q: a decimal amount needs the SKY unit", ErrCoins, s)
+	}
+
+	if amount == "" {
+		return 0, fmt.Errorf("%w %q: missing amount", ErrCoins, s)
+	}
+	if !isDigits(amount) {
+		return 0, fmt.Errorf("%w %q", ErrCoins, s)
+	}
+	droplets, err := strconv.ParseUint(amount, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q: out of range", ErrCoins, s)
+	}
+	return droplets, nil
+}
+
+// FormatCoins formats droplets in SKY, e.g. "1.5 SKY"
+func FormatCoins(droplets uint64) string {
+	frac := strings.TrimRight(fmt.Sprintf("%06d", droplets%DropletsPerSKY), "0")
+	if frac == "" {
+		return fmt.Sprintf("%d SKY", droplets/DropletsPerSKY)
+	}
+	return fmt.Sprintf("%d.%s SKY", droplets/DropletsPerSKY, frac)
+}
+
+func parseSKY(s, amount string) (uint64, error) {
+	whole, frac := amount, ""
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		whole, frac = amount[:i], amount[i+1:]
+	}
+	if amount == "" {
+		return 0, fmt.Errorf("%w %q: missing amount", ErrCoins, s)
+	}
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("%w %q", ErrCoins, s)
+	}
+	if len(frac) > dropletDecimals {
+		return 0, fmt.Errorf("%w %q: more than %d decimals", ErrCoins, s, dropletDecimals)
+	}
+
+	droplets, err := strconv.ParseUint("0"+whole+frac+strings.Repeat("0", dropletDecimals-len(frac)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q: out of range", ErrCoins, s)
+	}
+	return droplets, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Spec describes the transaction to sign, the inputs to spend and the outputs to create
+type Spec struct {
+	Inputs  []InputSpec
+	Outputs []OutputSpec
+}
+
+// InputSpec is an input of a Spec
+type InputSpec struct {
+	// Hash of the spent output
+	Hash cipher.SHA256
+	// Index of the address owning the spent output in the device wallet
+	Index uint32
+}
+
+// OutputSpec is an output of a Spec
+type OutputSpec struct {
+	Address cipher.Address
+	// Coins in droplets
+	Coins uint64
+	Hours uint64
+	// AddressIndex is the index of the address in the device wallet of a change output, nil for the other outputs
+	AddressIndex *uint32
+}
+
+// Transaction returns the unsigned transaction of the spec, with empty signatures, and the options to sign it
+func (s *Spec) Transaction() (*Transaction, SignOptions) {
+	txn := &Transaction{}
+	options := SignOptions{
+		ChangeIndexes: make(map[int]uint32),
+	}
+	for _, in := range s.Inputs {
+		txn.In = append(txn.In, in.Hash)
+		options.InputIndexes = append(options.InputIndexes, in.Index)
+	}
+	for i, out := range s.Outputs {
+		txn.Out = append(txn.Out, Output{Address: out.Address, Coins: out.Coins, Hours: out.Hours})
+		if out.AddressIndex != nil {
+			options.ChangeIndexes[i] = *out.AddressIndex
+		}
+	}
+	txn.Sigs = make([]cipher.Sig, len(txn.In))
+	txn.UpdateHeader()
+	return txn, options
+}
+
+// FieldError is an invalid field of a spec
+type FieldError struct {
+	// Field locates the field, e.g. outputs[1].coins in JSON or line 3 coins in CSV
+	Field string
+	Err   error
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// SpecError lists the invalid fields of a spec
+type SpecError []FieldError
+
+func (e SpecError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "invalid transaction spec: " + strings.Join(msgs, "; ")
+}
+
+// specParser builds a Spec from the values of its fields, collecting the errors of the invalid fields
+type specParser struct {
+	spec Spec
+	errs SpecError
+}
+
+// fail records the error of a field, only the first error of a field is kept
+func (p *specParser) fail(field string, err error) {
+	for _, e := range p.errs {
+		if e.Field == field {
+			return
+		}
+	}
+	p.errs = append(p.errs, FieldError{Field: field, Err: err})
+}
+
+func (p *specParser) input(field func(string) string, hash, index string) {
+	var in InputSpec
+	if hash == "" {
+		p.fail(field("hash"), errors.New("missing"))
+	} else if h, err := cipher.SHA256FromHex(hash); err != nil {
+		p.fail(field("hash"), fmt.Errorf("invalid hash %q: %v", hash, err))
+	} else {
+		in.Hash = h
+	}
+	if i, err := parseUint(index, 32); err != nil {
+		p.fail(field("index"), err)
+	} else {
+		in.Index = uint32(i)
+	}
+	p.spec.Inputs = append(p.spec.Inputs, in)
+}
+
+func (p *specParser) output(field func(string) string, address, coins, hours, addressIndex string) {
+	var out OutputSpec
+	var err error
+	if address == "" {
+		p.fail(field("address"), errors.New("missing"))
+	} else if out.Address, err = cipher.DecodeBase58Address(address); err != nil {
+		p.fail(field("address"), fmt.Errorf("invalid address %q: %v", address, err))
+	}
+	if coins == "" {
+		p.fail(field("coins"), errors.New("missing"))
+	} else if out.Coins, err = ParseCoins(coins); err != nil {
+		p.fail(field("coins"), err)
+	}
+	if out.Hours, err = parseUint(hours, 64); err != nil {
+		p.fail(field("hours"), err)
+	}
+	if addressIndex != "" {
+		if i, err := parseUint(addressIndex, 32); err != nil {
+			p.fail(field("addressIndex"), err)
+		} else {
+			index := uint32(i)
+			out.AddressIndex = &index
+		}
+	}
+	p.spec.Outputs = append(p.spec.Outputs, out)
+}
+
+func (p *specParser) result() (*Spec, error) {
+	if len(p.spec.Inputs) == 0 {
+		p.fail("inputs", errors.New("no input"))
+	}
+	if len(p.spec.Outputs) == 0 {
+		p.fail("outputs", errors.New("no output"))
+	}
+	if len(p.errs) != 0 {
+		return nil, p.errs
+	}
+	return &p.spec, nil
+}
+
+func parseUint(s string, bits int) (uint64, error) {
+	if s == "" {
+		return 0, errors.New("missing")
+	}
+	if !isDigits(s) {
+		return 0, fmt.Errorf("%q is not a positive integer", s)
+	}
+	n, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("%s out of range", s)
+	}
+	return n, nil
+}
+
+type jsonSpec struct {
+	Inputs  []jsonInput  `json:"inputs"`
+	Outputs []jsonOutput `json:"outputs"`
+}
+
+type jsonInput struct {
+	Hash  interface{} `json:"hash"`
+	Index interface{} `json:"index"`
+}
+
+type jsonOutput struct {
+	Address      interface{} `json:"address"`
+	Coins        interface{} `json:"coins"`
+	Hours        interface{} `json:"hours"`
+	AddressIndex interface{} `json:"addressIndex"`
+}
+
+// ParseSpecJSON reads a JSON transaction spec such as
+//
+//	{
+//	  "inputs": [{"hash": "181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9", "index": 0}],
+//	  "outputs": [
+//	    {"address": "K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot", "coins": "1.5 SKY", "hours": 2},
+//	    {"address": "2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw", "coins": 100000, "hours": 1, "addressIndex": 0}
+//	  ]
+//	}
+//
+// The coins are given as a number of droplets or as a string, see ParseCoins. The invalid fields are returned in a SpecError.
+func ParseSpecJSON(r io.Reader) (*Spec, error) {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	d.DisallowUnknownFields()
+	var raw jsonSpec
+	if err := d.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid transaction spec: %v", err)
+	}
+	if d.More() {
+		return nil, errors.New("invalid transaction spec: data after the spec")
+	}
+
+	var p specParser
+	for i, in := range raw.Inputs {
+		field := jsonField("inputs", i)
+		p.input(field, jsonString(&p, field("hash"), in.Hash), jsonNumber(&p, field("index"), in.Index))
+	}
+	for i, out := range raw.Outputs {
+		field := jsonField("outputs", i)
+		coins := ""
+		switch v := out.Coins.(type) {
+		case json.Number:
+			coins = v.String()
+		default:
+			coins = jsonString(&p, field("coins"), v)
+		}
+		p.output(field, jsonString(&p, field("address"), out.Address), coins,
+			jsonNumber(&p, field("hours"), out.Hours), jsonNumber(&p, field("addressIndex"), out.AddressIndex))
+	}
+	return p.result()
+}
+
+func jsonField(list string, i int) func(string) string {
+	return func(name string) string {
+		return fmt.Sprintf("%s[%d].%s", list, i, name)
+	}
+}
+
+// jsonString returns the value of a string field, the empty string for a missing field
+func jsonString(p *specParser, field string, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+	case string:
+		return v
+	default:
+		p.fail(field, fmt.Errorf("%v is not a string", v))
+	}
+	return ""
+}
+
+// jsonNumber returns the value of a number field, the empty string for a missing field
+func jsonNumber(p *specParser, field string, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+	case json.Number:
+		return v.String()
+	case string:
+		p.fail(field, fmt.Errorf("%q is a string, not a number", v))
+	default:
+		p.fail(field, fmt.Errorf("%v is not a number", v))
+	}
+	return ""
+}
+
+// csvColumns are the columns of a CSV transaction spec
+var csvColumns = []string{"type", "hash", "index", "address", "coins", "hours", "addressIndex"}
+
+// ParseSpecCSV reads a CSV transaction spec, one input or output per line, such as
+//
+//	type,hash,index,address,coins,hours,addressIndex
+//	input,181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9,0,,,,
+//	output,,,K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot,1.5 SKY,2,
+//	output,,,2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw,100000,1,0
+//
+// The header line names the columns, in any order, the columns unused by the inputs or the outputs may be omitted.
+// Empty lines and lines starting with # are ignored. The invalid fields are returned in a SpecError.
+func ParseSpecCSV(r io.Reader) (*Spec, error) {
+	var p specParser
+	var columns map[string]int
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		cr := csv.NewReader(strings.NewReader(text))
+		cr.TrimLeadingSpace = true
+		record, err := cr.Read()
+		if err != nil {
+			return nil, fmt.Errorf("invalid transaction spec: line %d: %v", line, err)
+		}
+
+		if columns == nil {
+			if columns, err = csvHeader(record); err != nil {
+				return nil, fmt.Errorf("invalid transaction spec: line %d: %v", line, err)
+			}
+			continue
+		}
+		if len(record) != len(columns) {
+			return nil, fmt.Errorf("invalid transaction spec: line %d: %d fields, expecting %d", line, len(record), len(columns))
+		}
+
+		l := line
+		field := func(name string) string {
+			return fmt.Sprintf("line %d %s", l, name)
+		}
+		value := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		// unused reports the fields set but unused by the type of the line
+		unused := func(names ...string) {
+			for _, name := range names {
+				if value(name) != "" {
+					p.fail(field(name), fmt.Errorf("not a field of an %s", value("type")))
+				}
+			}
+		}
+
+		switch value("type") {
+		case "input":
+			unused("address", "coins", "hours", "addressIndex")
+			p.input(field, value("hash"), value("index"))
+		case "output":
+			unused("hash", "index")
+			p.output(field, value("address"), value("coins"), value("hours"), value("addressIndex"))
+		default:
+			p.fail(field("type"), fmt.Errorf("%q is neither input nor output", value("type")))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if columns == nil {
+		return nil, errors.New("invalid transaction spec: no header line")
+	}
+	return p.result()
+}
+
+// csvHeader returns the position of the columns named by the header line
+func csvHeader(record []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, name := range record {
+		name = strings.TrimSpace(name)
+		known := false
+		for _, column := range csvColumns {
+			known = known || column == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column %q, expecting %s", name, strings.Join(csvColumns, ","))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["type"]; !ok {
+		return nil, errors.New("no type column")
+	}
+	return columns, nil
+}
@@ -0,0 +1,144 @@
+package transaction
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCoins(t *testing.T) {
+	for s, droplets := range map[string]uint64{
+		"100000":                    100000,
+		"100000 droplets":           100000,
+		"100000droplets":            100000,
+		"1 SKY":                     1000000,
+		"1.5 SKY":                   1500000,
+		"0.000001sky":               1,
+		".25 SKY":                   250000,
+		"2. SKY":                    2000000,
+		"18446744073709.551615 SKY": 18446744073709551615,
+	} {
+		coins, err := ParseCoins(s)
+		require.NoError(t, err, s)
+		require.Equal(t, droplets, coins, s)
+	}
+
+	for _, s := range []string{"", "1.5", "-1", "+1", "1e6", "SKY", ". SKY", "1.0000001 SKY", "1,5 SKY",
+		"18446744073709551616", "18446744073709.551616 SKY", "1.5 droplets", "one SKY"} {
+		_, err := ParseCoins(s)
+		require.True(t, errors.Is(err, ErrCoins), "%q: %v", s, err)
+	}
+
+	for _, s := range []string{"", "droplets", " droplets", "SKY", " sky "} {
+		_, err := ParseCoins(s)
+		require.True(t, errors.Is(err, ErrCoins), "%q: %v", s, err)
+		require.Contains(t, err.Error(), "missing amount", s)
+	}
+}
+
+func TestFormatCoins(t *testing.T) {
+	for droplets, s := range map[uint64]string{
+		0:                    "0 SKY",
+		1:                    "0.000001 SKY",
+		1500000:              "1.5 SKY",
+		2000000:              "2 SKY",
+		18446744073709551615: "18446744073709.551615 SKY",
+	} {
+		require.Equal(t, s, FormatCoins(droplets))
+		coins, err := ParseCoins(s)
+		require.NoError(t, err)
+		require.Equal(t, droplets, coins)
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	index := uint32(0)
+	expected := &Spec{
+		Inputs: []InputSpec{{Hash: cipher.MustSHA256FromHex("181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9")}},
+		Outputs: []OutputSpec{
+			{Address: cipher.MustDecodeBase58Address("K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot"), Coins: 1500000, Hours: 2},
+			{Address: cipher.MustDecodeBase58Address("2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw"), Coins: 100000, Hours: 1, AddressIndex: &index},
+		},
+	}
+
+	spec, err := ParseSpecJSON(strings.NewReader(`{
+		"inputs": [{"hash": "181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9", "index": 0}],
+		"outputs": [
+			{"address": "K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot", "coins": "1.5 SKY", "hours": 2},
+			{"address": "2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw", "coins": 100000, "hours": 1, "addressIndex": 0}
+		]
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, expected, spec)
+
+	spec, err = ParseSpecCSV(strings.NewReader(`# the sample transaction
+type,hash,index,address,coins,hours,addressIndex
+input,181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9,0,,,,
+
+output,,,K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot,1.5 SKY,2,
+output, , ,2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw,100000 droplets,1,0
+`))
+	require.NoError(t, err)
+	require.Equal(t, expected, spec)
+
+	// columns in any order, the unused ones omitted
+	spec, err = ParseSpecCSV(strings.NewReader(`address,type,coins,hours,index,hash
+,input,,,0,181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9
+K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot,output,100000,2,,
+`))
+	require.NoError(t, err)
+
+	txn, options := spec.Transaction()
+	require.Equal(t, testTransaction(), txn)
+	require.Equal(t, SignOptions{InputIndexes: []uint32{0}, ChangeIndexes: map[int]uint32{}}, options)
+}
+
+func TestParseSpecErrors(t *testing.T) {
+	_, err := ParseSpecJSON(strings.NewReader(`{
+		"inputs": [{"hash": "181bd5", "index": -1}, {"index": "1"}],
+		"outputs": [
+			{"address": "K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ou", "coins": 1.5, "hours": 2},
+			{"address": "2EU3JbveHdkxW6z5tdhbbB2kRAWvXC2pLzw", "coins": true, "addressIndex": 4294967296}
+		]
+	}`))
+	require.IsType(t, SpecError{}, err)
+	var fields []string
+	for _, e := range err.(SpecError) {
+		fields = append(fields, e.Field)
+	}
+	require.Equal(t, []string{
+		"inputs[0].hash", "inputs[0].index",
+		"inputs[1].index", "inputs[1].hash",
+		"outputs[0].address", "outputs[0].coins",
+		"outputs[1].coins", "outputs[1].hours", "outputs[1].addressIndex",
+	}, fields)
+	require.Contains(t, err.Error(), `outputs[0].coins: invalid coins "1.5": a decimal amount needs the SKY unit`)
+	require.Contains(t, err.Error(), `inputs[1].index: "1" is a string, not a number`)
+	require.Contains(t, err.Error(), "outputs[1].hours: missing")
+
+	_, err = ParseSpecJSON(strings.NewReader(`{"inputs": [], "outputs": [], "fee": 1}`))
+	require.EqualError(t, err, `invalid transaction spec: json: unknown field "fee"`)
+
+	_, err = ParseSpecJSON(strings.NewReader(`{"inputs": [], "outputs": []}`))
+	require.EqualError(t, err, "invalid transaction spec: inputs: no input; outputs: no output")
+
+	_, err = ParseSpecCSV(strings.NewReader(`type,hash,index,address,coins,hours
+input,181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9,0,K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot,,
+output,,,K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot,2 SKY,x
+change,,,,,
+`))
+	require.EqualError(t, err, "invalid transaction spec: line 2 address: not a field of an input; "+
+		`line 3 hours: "x" is not a positive integer; line 4 type: "change" is neither input nor output`)
+
+	_, err = ParseSpecCSV(strings.NewReader("type,hash,fee\n"))
+	require.EqualError(t, err, `invalid transaction spec: line 1: unknown column "fee", expecting type,hash,index,address,coins,hours,addressIndex`)
+
+	_, err = ParseSpecCSV(strings.NewReader("type,hash\ninput\n"))
+	require.EqualError(t, err, "invalid transaction spec: line 2: 1 fields, expecting 2")
+
+	_, err = ParseSpecCSV(strings.NewReader("# nothing\n"))
+	require.EqualError(t, err, "invalid transaction spec: no header line")
+}
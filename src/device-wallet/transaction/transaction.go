@@ -0,0 +1,248 @@
+/*
+Package transaction decodes and encodes Skycoin transactions, as produced by createRawTransaction
+and consumed by injectTransaction, and has them signed by the device.
+
+A transaction is encoded, integers in little endian, as:
+
+	uint32 length of the encoded transaction
+	uint8  type, 0
+	32     inner hash, the SHA256 of the encoded inputs and outputs
+	uint32 number of signatures, then the 65 bytes signatures, one per input
+	uint32 number of inputs, then the 32 bytes hashes of the spent outputs
+	uint32 number of outputs, then for each the address version byte, the 20 bytes
+	       address key, the uint64 coins in droplets and the uint64 coin hours
+*/
+package transaction
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+const (
+	// headerSize is the size of the length, type and inner hash
+	headerSize = 4 + 1 + 32
+	// outputSize is the size of an encoded output
+	outputSize = 1 + 20 + 8 + 8
+)
+
+var (
+	// ErrMalformed is returned for bytes not encoding a transaction
+	ErrMalformed = errors.New("malformed transaction")
+	// ErrInnerHash is returned when the inner hash of a transaction does not match its inputs and outputs
+	ErrInnerHash = errors.New("inner hash does not match the inputs and outputs")
+)
+
+// Output of a transaction
+type Output struct {
+	Address cipher.Address
+	// Coins in droplets
+	Coins uint64
+	Hours uint64
+}
+
+// Transaction is a Skycoin transaction
+type Transaction struct {
+	Length    uint32
+	Type      uint8
+	InnerHash cipher.SHA256
+	Sigs      []cipher.Sig
+	In        []cipher.SHA256
+	Out       []Output
+}
+
+// HashInner returns the SHA256 of the encoded inputs and outputs, signed along with each input
+func (txn *Transaction) HashInner() cipher.SHA256 {
+	var b bytes.Buffer
+	txn.writeInner(&b)
+	return cipher.SumSHA256(b.Bytes())
+}
+
+// SignatureHash returns the hash signed for the input i
+func (txn *Transaction) SignatureHash(i int) cipher.SHA256 {
+	return cipher.AddSHA256(txn.InnerHash, txn.In[i])
+}
+
+// Hash returns the transaction id, the SHA256 of the encoded transaction
+func (txn *Transaction) Hash() cipher.SHA256 {
+	return cipher.SumSHA256(txn.Serialize())
+}
+
+// UpdateHeader sets the length and the inner hash of the transaction
+func (txn *Transaction) UpdateHeader() {
+	txn.Length = uint32(headerSize + 4 + len(txn.Sigs)*len(cipher.Sig{}) +
+		4 + len(txn.In)*len(cipher.SHA256{}) + 4 + len(txn.Out)*outputSize)
+	txn.InnerHash = txn.HashInner()
+}
+
+// Signed reports whether every input of the transaction has a signature
+func (txn *Transaction) Signed() bool {
+	if len(txn.Sigs) != len(txn.In) {
+		return false
+	}
+	for _, sig := range txn.Sigs {
+		if sig == (cipher.Sig{}) {
+			return false
+		}
+	}
+	return true
+}
+
+// Serialize encodes the transaction
+func (txn *Transaction) Serialize() []byte {
+	var b bytes.Buffer
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], txn.Length)
+	b.Write(n[:])
+	b.WriteByte(txn.Type)
+	b.Write(txn.InnerHash[:])
+
+	binary.LittleEndian.PutUint32(n[:], uint32(len(txn.Sigs)))
+	b.Write(n[:])
+	for _, sig := range txn.Sigs {
+		b.Write(sig[:])
+	}
+	txn.writeInner(&b)
+	return b.Bytes()
+}
+
+// SerializeHex encodes the transaction in hex
+func (txn *Transaction) SerializeHex() string {
+	return hex.EncodeToString(txn.Serialize())
+}
+
+func (txn *Transaction) writeInner(b *bytes.Buffer) {
+	var n [8]byte
+	binary.LittleEndian.PutUint32(n[:4], uint32(len(txn.In)))
+	b.Write(n[:4])
+	for _, in := range txn.In {
+		b.Write(in[:])
+	}
+
+	binary.LittleEndian.PutUint32(n[:4], uint32(len(txn.Out)))
+	b.Write(n[:4])
+	for _, out := range txn.Out {
+		b.WriteByte(out.Address.Version)
+		b.Write(out.Address.Key[:])
+		binary.LittleEndian.PutUint64(n[:], out.Coins)
+		b.Write(n[:])
+		binary.LittleEndian.PutUint64(n[:], out.Hours)
+		b.Write(n[:])
+	}
+}
+
+// Deserialize decodes a transaction, its length and inner hash are checked
+func Deserialize(data []byte) (*Transaction, error) {
+	d := decoder{data: data}
+	txn := &Transaction{
+		Length: d.uint32(),
+		Type:   d.byte(),
+	}
+	copy(txn.InnerHash[:], d.bytes(len(txn.InnerHash)))
+
+	if n := d.count(len(cipher.Sig{})); n > 0 {
+		txn.Sigs = make([]cipher.Sig, n)
+		for i := range txn.Sigs {
+			copy(txn.Sigs[i][:], d.bytes(len(cipher.Sig{})))
+		}
+	}
+	if n := d.count(len(cipher.SHA256{})); n > 0 {
+		txn.In = make([]cipher.SHA256, n)
+		for i := range txn.In {
+			copy(txn.In[i][:], d.bytes(len(cipher.SHA256{})))
+		}
+	}
+	if n := d.count(outputSize); n > 0 {
+		txn.Out = make([]Output, n)
+		for i := range txn.Out {
+			out := &txn.Out[i]
+			out.Address.Version = d.byte()
+			copy(out.Address.Key[:], d.bytes(len(out.Address.Key)))
+			out.Coins = d.uint64()
+			out.Hours = d.uint64()
+		}
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+	if len(d.data) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrMalformed, len(d.data))
+	}
+	if int(txn.Length) != len(data) {
+		return nil, fmt.Errorf("%w: length %d, %d bytes", ErrMalformed, txn.Length, len(data))
+	}
+	if txn.Type != 0 {
+		return nil, fmt.Errorf("%w: type %d", ErrMalformed, txn.Type)
+	}
+	if txn.InnerHash != txn.HashInner() {
+		return nil, ErrInnerHash
+	}
+	return txn, nil
+}
+
+// DeserializeHex decodes a hex encoded transaction, see Deserialize
+func DeserializeHex(s string) (*Transaction, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return Deserialize(data)
+}
+
+// decoder reads the fields of a transaction, the first error is kept and the next reads return zeros
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.data) < n {
+		d.err = fmt.Errorf("%w: truncated", ErrMalformed)
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) byte() byte {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// count reads the number of elements of a list, refusing more elements of size than bytes left
+func (d *decoder) count(size int) int {
+	n := d.uint32()
+	if d.err == nil && uint64(n)*uint64(size) > uint64(len(d.data)) {
+		d.err = fmt.Errorf("%w: %d elements of %d bytes, %d bytes left", ErrMalformed, n, size, len(d.data))
+	}
+	if d.err != nil {
+		return 0
+	}
+	return int(n)
+}
@@ -0,0 +1,112 @@
+package transaction
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+// testTransaction returns the unsigned transaction of the first sample of the integration tests
+func testTransaction() *Transaction {
+	txn := &Transaction{
+		In: []cipher.SHA256{cipher.MustSHA256FromHex("181bd5656115172fe81451fae4fb56498a97744d89702e73da75ba91ed5200f9")},
+		Out: []Output{{
+			Address: cipher.MustDecodeBase58Address("K9TzLrgqz7uXn3QJHGxmzdRByAzH33J2ot"),
+			Coins:   100000,
+			Hours:   2,
+		}},
+	}
+	txn.Sigs = make([]cipher.Sig, len(txn.In))
+	txn.UpdateHeader()
+	return txn
+}
+
+func TestSerialize(t *testing.T) {
+	txn := testTransaction()
+	require.Equal(t, "d11c62b1e0e9abf629b1f5f4699cef9fbc504b45ceedf0047ead686979498218", txn.SignatureHash(0).Hex())
+
+	data := txn.Serialize()
+	require.Len(t, data, int(txn.Length))
+	require.Equal(t, 183, len(data))
+
+	decoded, err := DeserializeHex(txn.SerializeHex())
+	require.NoError(t, err)
+	require.Equal(t, txn, decoded)
+	require.Equal(t, txn.Hash(), decoded.Hash())
+	require.False(t, decoded.Signed())
+
+	// without signatures
+	txn.Sigs = nil
+	txn.UpdateHeader()
+	decoded, err = Deserialize(txn.Serialize())
+	require.NoError(t, err)
+	require.Equal(t, txn, decoded)
+}
+
+func TestDeserializeMalformed(t *testing.T) {
+	data := testTransaction().Serialize()
+
+	cases := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{
+			name: "truncated",
+			data: data[:len(data)-1],
+			err:  ErrMalformed,
+		},
+		{
+			name: "trailing bytes",
+			data: append(append([]byte(nil), data...), 0),
+			err:  ErrMalformed,
+		},
+		{
+			name: "wrong length",
+			data: func() []byte {
+				b := append([]byte(nil), data...)
+				b[0]++
+				return b
+			}(),
+			err: ErrMalformed,
+		},
+		{
+			name: "wrong type",
+			data: func() []byte {
+				b := append([]byte(nil), data...)
+				b[4] = 1
+				return b
+			}(),
+			err: ErrMalformed,
+		},
+		{
+			name: "huge count",
+			data: func() []byte {
+				b := append([]byte(nil), data...)
+				b[headerSize+3] = 0xff
+				return b
+			}(),
+			err: ErrMalformed,
+		},
+		{
+			name: "tampered output",
+			data: func() []byte {
+				b := append([]byte(nil), data...)
+				b[len(b)-1] ^= 1
+				return b
+			}(),
+			err: ErrInnerHash,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Deserialize(tc.data)
+			require.True(t, errors.Is(err, tc.err), "%v", err)
+		})
+	}
+
+	_, err := DeserializeHex("zz")
+	require.True(t, errors.Is(err, ErrMalformed))
+}
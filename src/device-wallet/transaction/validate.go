@@ -0,0 +1,233 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+const (
+	// DefaultBurnFactor is the burn factor of the Skycoin nodes, the inverse of the share of the input coin hours burned as fee
+	DefaultBurnFactor = 2
+	// MaxDropletPrecision is the number of decimals, in SKY, of the coins of an output
+	MaxDropletPrecision = 3
+	// secondsPerHour converts coin seconds to coin hours
+	secondsPerHour = 3600
+)
+
+var (
+	// ErrZeroCoins is returned for an output without coins
+	ErrZeroCoins = errors.New("output without coins")
+	// ErrDropletPrecision is returned for output coins with more decimals than MaxDropletPrecision
+	ErrDropletPrecision = errors.New("coins not a multiple of the droplet precision")
+	// ErrDuplicateInput is returned for an output spent twice by a transaction
+	ErrDuplicateInput = errors.New("duplicate input")
+	// ErrCoinsOverflow is returned when a sum of coins overflows
+	ErrCoinsOverflow = errors.New("coins overflow")
+	// ErrHoursOverflow is returned when a sum or computation of coin hours overflows
+	ErrHoursOverflow = errors.New("coin hours overflow")
+	// ErrUnknownUxout is returned for an input without its uxout
+	ErrUnknownUxout = errors.New("unknown uxout")
+	// ErrCoinsMismatch is returned when the coins of the outputs differ from the coins of the inputs
+	ErrCoinsMismatch = errors.New("output coins differ from the input coins")
+	// ErrInsufficientHours is returned when the outputs have more coin hours than the inputs
+	ErrInsufficientHours = errors.New("insufficient coin hours")
+	// ErrInsufficientFee is returned when less coin hours than required by the burn factor are burned
+	ErrInsufficientFee = errors.New("insufficient coin hour fee")
+	// ErrHeadTime is returned for a head time earlier than the creation of an uxout
+	ErrHeadTime = errors.New("head time earlier than the uxout")
+)
+
+// ValidateOptions give the uxouts spent by a transaction and the rules its coin hours follow
+type ValidateOptions struct {
+	// Uxouts spent by the inputs, in any order
+	Uxouts []Uxout
+	// HeadTime is the time, in unix seconds, of the head block of the blockchain, the uxouts coin hours are computed at it
+	HeadTime uint64
+	// BurnFactor of the coin hours, DefaultBurnFactor when 0
+	BurnFactor uint32
+}
+
+// ValidationError lists the rules broken by a transaction, it matches the error of each rule with errors.Is
+type ValidationError []error
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "invalid transaction: " + strings.Join(msgs, "; ")
+}
+
+// Is reports whether target is the error of a broken rule
+func (e ValidationError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// UxoutHours returns the coin hours of the uxout at headTime, its hours grow by one per whole SKY and hour.
+// It returns ErrHeadTime when headTime is earlier than the time of the uxout.
+func UxoutHours(ux Uxout, headTime uint64) (uint64, error) {
+	if headTime < ux.Time {
+		return 0, ErrHeadTime
+	}
+	seconds := headTime - ux.Time
+
+	wholeCoinSeconds, ok := mul(seconds, ux.Coins/DropletsPerSKY)
+	if !ok {
+		return 0, ErrHoursOverflow
+	}
+	dropletSeconds, ok := mul(seconds, ux.Coins%DropletsPerSKY)
+	if !ok {
+		return 0, ErrHoursOverflow
+	}
+	coinSeconds, ok := add(wholeCoinSeconds, dropletSeconds/DropletsPerSKY)
+	if !ok {
+		return 0, ErrHoursOverflow
+	}
+	hours, ok := add(ux.Hours, coinSeconds/secondsPerHour)
+	if !ok {
+		return 0, ErrHoursOverflow
+	}
+	return hours, nil
+}
+
+// RequiredFee returns the coin hours to burn when spending hours with burnFactor, rounded up
+func RequiredFee(hours uint64, burnFactor uint32) uint64 {
+	fee := hours / uint64(burnFactor)
+	if hours%uint64(burnFactor) != 0 {
+		fee++
+	}
+	return fee
+}
+
+// Validate checks the outputs of txn have coins, aligned on MaxDropletPrecision, and its inputs are distinct.
+// When options has uxouts, the coins of the outputs are checked to be the coins of the inputs, and the coin hours
+// of the outputs to leave the fee required by the burn factor. The broken rules are returned in a ValidationError.
+func Validate(txn *Transaction, options *ValidateOptions) error {
+	var errs ValidationError
+
+	spent := make(map[cipher.SHA256]bool, len(txn.In))
+	for i, in := range txn.In {
+		if spent[in] {
+			errs = append(errs, fmt.Errorf("%w: input %d %s", ErrDuplicateInput, i, in.Hex()))
+		}
+		spent[in] = true
+	}
+
+	precision := uint64(1)
+	for i := 0; i < dropletDecimals-MaxDropletPrecision; i++ {
+		precision *= 10
+	}
+	var coinsOut, hoursOut uint64
+	coinsOverflow, hoursOverflow := false, false
+	for i, out := range txn.Out {
+		if out.Coins == 0 {
+			errs = append(errs, fmt.Errorf("%w: output %d", ErrZeroCoins, i))
+		} else if out.Coins%precision != 0 {
+			errs = append(errs, fmt.Errorf("%w: output %d has %s, at most %d decimals", ErrDropletPrecision, i,
+				FormatCoins(out.Coins), MaxDropletPrecision))
+		}
+		var ok bool
+		if coinsOut, ok = add(coinsOut, out.Coins); !ok {
+			coinsOverflow = true
+		}
+		if hoursOut, ok = add(hoursOut, out.Hours); !ok {
+			hoursOverflow = true
+		}
+	}
+	if coinsOverflow {
+		errs = append(errs, fmt.Errorf("%w: outputs", ErrCoinsOverflow))
+	}
+	if hoursOverflow {
+		errs = append(errs, fmt.Errorf("%w: outputs", ErrHoursOverflow))
+	}
+
+	if options != nil && !coinsOverflow && !hoursOverflow {
+		errs = append(errs, validateHours(txn, options, coinsOut, hoursOut)...)
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateHours checks the coins and coin hours of the outputs against the uxouts of the inputs
+func validateHours(txn *Transaction, options *ValidateOptions, coinsOut, hoursOut uint64) ValidationError {
+	burnFactor := options.BurnFactor
+	if burnFactor == 0 {
+		burnFactor = DefaultBurnFactor
+	}
+	uxouts := make(map[cipher.SHA256]Uxout, len(options.Uxouts))
+	for _, ux := range options.Uxouts {
+		uxouts[ux.Hash] = ux
+	}
+
+	var errs ValidationError
+	var coinsIn, hoursIn uint64
+	counted := make(map[cipher.SHA256]bool, len(txn.In))
+	for i, in := range txn.In {
+		ux, ok := uxouts[in]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: input %d %s", ErrUnknownUxout, i, in.Hex()))
+			continue
+		}
+		if counted[in] {
+			continue
+		}
+		counted[in] = true
+
+		if coinsIn, ok = add(coinsIn, ux.Coins); !ok {
+			return append(errs, fmt.Errorf("%w: inputs", ErrCoinsOverflow))
+		}
+		if options.HeadTime < ux.Time {
+			errs = append(errs, fmt.Errorf("%w: input %d created at %d, head time %d", ErrHeadTime, i, ux.Time, options.HeadTime))
+			continue
+		}
+		hours, err := UxoutHours(ux, options.HeadTime)
+		if err != nil {
+			return append(errs, fmt.Errorf("%w: input %d", err, i))
+		}
+		if hoursIn, ok = add(hoursIn, hours); !ok {
+			return append(errs, fmt.Errorf("%w: inputs", ErrHoursOverflow))
+		}
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+
+	if coinsIn != coinsOut {
+		errs = append(errs, fmt.Errorf("%w: %s in, %s out", ErrCoinsMismatch, FormatCoins(coinsIn), FormatCoins(coinsOut)))
+	}
+	fee := RequiredFee(hoursIn, burnFactor)
+	switch {
+	case hoursOut > hoursIn:
+		errs = append(errs, fmt.Errorf("%w: the outputs have %d hours, the inputs %d hours of which %d are burned",
+			ErrInsufficientHours, hoursOut, hoursIn, fee))
+	case hoursIn-hoursOut == 0:
+		errs = append(errs, fmt.Errorf("%w: no coin hour burned, %d required", ErrInsufficientFee, fee))
+	case hoursIn-hoursOut < fee:
+		errs = append(errs, fmt.Errorf("%w: %d hours burned, %d required with burn factor %d, at most %d hours can be sent",
+			ErrInsufficientFee, hoursIn-hoursOut, fee, burnFactor, hoursIn-fee))
+	}
+	return errs
+}
+
+func add(a, b uint64) (uint64, bool) {
+	c := a + b
+	return c, c >= a
+}
+
+func mul(a, b uint64) (uint64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	return c, c/b == a
+}
@@ -0,0 +1,118 @@
+package transaction
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUxoutHours(t *testing.T) {
+	ux := Uxout{Coins: 2500000, Hours: 10, Time: 1000}
+
+	for headTime, hours := range map[uint64]uint64{
+		1000:          10,
+		1000 + 3599:   10 + 2,
+		1000 + 3600*4: 10 + 10,
+	} {
+		h, err := UxoutHours(ux, headTime)
+		require.NoError(t, err)
+		require.Equal(t, hours, h, "%d", headTime)
+	}
+
+	_, err := UxoutHours(ux, 999)
+	require.Equal(t, ErrHeadTime, err)
+
+	ux = Uxout{Coins: math.MaxUint64}
+	_, err = UxoutHours(ux, 1<<20)
+	require.Equal(t, ErrHoursOverflow, err)
+	ux = Uxout{Coins: DropletsPerSKY, Hours: math.MaxUint64}
+	_, err = UxoutHours(ux, 3600)
+	require.Equal(t, ErrHoursOverflow, err)
+}
+
+func TestRequiredFee(t *testing.T) {
+	require.Equal(t, uint64(0), RequiredFee(0, 2))
+	require.Equal(t, uint64(1), RequiredFee(1, 2))
+	require.Equal(t, uint64(5), RequiredFee(10, 2))
+	require.Equal(t, uint64(2), RequiredFee(11, 10))
+}
+
+func TestValidate(t *testing.T) {
+	txn := testTransaction()
+	options := &ValidateOptions{Uxouts: testUxouts()}
+	require.NoError(t, Validate(txn, nil))
+	require.NoError(t, Validate(txn, options))
+
+	// 10 hours in, 5 burned at most 5 sent
+	txn.Out[0].Hours = 5
+	require.NoError(t, Validate(txn, options))
+	txn.Out[0].Hours = 6
+	err := Validate(txn, options)
+	require.True(t, errors.Is(err, ErrInsufficientFee), "%v", err)
+	require.EqualError(t, err, "invalid transaction: insufficient coin hour fee: 4 hours burned, "+
+		"5 required with burn factor 2, at most 5 hours can be sent")
+	txn.Out[0].Hours = 10
+	require.True(t, errors.Is(Validate(txn, options), ErrInsufficientFee))
+	txn.Out[0].Hours = 11
+	require.True(t, errors.Is(Validate(txn, options), ErrInsufficientHours))
+	require.NoError(t, Validate(txn, nil))
+
+	// the uxout hours grow until the head time, the burn factor sets the fee
+	options = &ValidateOptions{Uxouts: testUxouts(), HeadTime: 3600 * 100, BurnFactor: 10}
+	txn.Out[0].Hours = 11
+	require.NoError(t, Validate(txn, options))
+	txn.Out[0].Hours = 19
+	require.True(t, errors.Is(Validate(txn, options), ErrInsufficientFee))
+
+	// the head time cannot precede the creation of the uxouts
+	options.Uxouts[0].Time = options.HeadTime + 1
+	err = Validate(txn, options)
+	require.True(t, errors.Is(err, ErrHeadTime), "%v", err)
+	require.EqualError(t, err, "invalid transaction: head time earlier than the uxout: input 0 created at 360001, head time 360000")
+
+	txn = testTransaction()
+	txn.Out[0].Coins--
+	err = Validate(txn, &ValidateOptions{Uxouts: testUxouts()})
+	require.True(t, errors.Is(err, ErrCoinsMismatch), "%v", err)
+	require.True(t, errors.Is(err, ErrDropletPrecision), "%v", err)
+
+	err = Validate(txn, &ValidateOptions{})
+	require.True(t, errors.Is(err, ErrUnknownUxout), "%v", err)
+}
+
+func TestValidateOutputs(t *testing.T) {
+	txn := testTransaction()
+	txn.In = append(txn.In, txn.In[0])
+	txn.Out = append(txn.Out,
+		Output{Address: txn.Out[0].Address},
+		Output{Address: txn.Out[0].Address, Coins: 1500},
+		Output{Address: txn.Out[0].Address, Coins: math.MaxUint64 - math.MaxUint64%1000, Hours: math.MaxUint64},
+	)
+	txn.UpdateHeader()
+
+	err := Validate(txn, &ValidateOptions{Uxouts: testUxouts()})
+	require.IsType(t, ValidationError{}, err)
+	for _, rule := range []error{ErrDuplicateInput, ErrZeroCoins, ErrDropletPrecision, ErrCoinsOverflow, ErrHoursOverflow} {
+		require.True(t, errors.Is(err, rule), "%v: %v", rule, err)
+	}
+	require.False(t, errors.Is(err, ErrInsufficientFee))
+	require.EqualError(t, err, "invalid transaction: duplicate input: input 1 "+txn.In[0].Hex()+"; output without coins: output 1; "+
+		"coins not a multiple of the droplet precision: output 2 has 0.0015 SKY, at most 3 decimals; "+
+		"coins overflow: outputs; coin hours overflow: outputs")
+
+	// the device is never asked to sign an invalid transaction
+	_, _, err = Sign(nil, txn, SignOptions{InputIndexes: []uint32{0, 0}})
+	require.True(t, errors.Is(err, ErrZeroCoins), "%v", err)
+
+	// the inputs hours overflow
+	txn = testTransaction()
+	uxouts := testUxouts()
+	uxouts = append(uxouts, Uxout{Hash: cipher.SumSHA256([]byte("other")), Hours: math.MaxUint64})
+	txn.In = append(txn.In, uxouts[1].Hash)
+	txn.UpdateHeader()
+	err = Validate(txn, &ValidateOptions{Uxouts: uxouts})
+	require.True(t, errors.Is(err, ErrHoursOverflow), "%v", err)
+}
@@ -0,0 +1,116 @@
+package transaction
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+)
+
+// Owners maps the hashes of the outputs spent by the inputs to the addresses owning them
+type Owners map[cipher.SHA256]cipher.Address
+
+// OwnersFromDevice returns the owners of the inputs of txn, the addresses of the device wallet at inputIndexes
+func OwnersFromDevice(device deviceWallet.Devicer, txn *Transaction, inputIndexes []uint32) (Owners, error) {
+	if len(inputIndexes) != len(txn.In) {
+		return nil, fmt.Errorf("%d input indexes given for %d inputs", len(inputIndexes), len(txn.In))
+	}
+
+	client := deviceWallet.NewClient(device)
+	addresses := make(map[uint32]cipher.Address)
+	owners := make(Owners, len(txn.In))
+	for i, in := range txn.In {
+		index := inputIndexes[i]
+		addr, ok := addresses[index]
+		if !ok {
+			derived, err := client.AddressGen(1, int(index), false)
+			if err != nil {
+				return nil, err
+			}
+			if len(derived) != 1 {
+				return nil, fmt.Errorf("%d addresses derived at index %d", len(derived), index)
+			}
+			if addr, err = cipher.DecodeBase58Address(derived[0]); err != nil {
+				return nil, err
+			}
+			addresses[index] = addr
+		}
+		owners[in] = addr
+	}
+	return owners, nil
+}
+
+// SignatureError is the failed verification of the signature of an input
+type SignatureError struct {
+	Input int
+	Hash  cipher.SHA256
+	// Owner is the address owning the input, Signer the address of the public key recovered from the signature
+	Owner  cipher.Address
+	Signer cipher.Address
+	Err    error
+}
+
+func (e SignatureError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("input %d %s: %v", e.Input, e.Hash.Hex(), e.Err)
+	}
+	return fmt.Sprintf("input %d %s: signed by %s instead of its owner %s", e.Input, e.Hash.Hex(), e.Signer, e.Owner)
+}
+
+func (e SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// SignaturesError lists the inputs whose signature failed the verification
+type SignaturesError []SignatureError
+
+func (e SignaturesError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "invalid transaction signatures: " + strings.Join(msgs, "; ")
+}
+
+// VerifySignatures checks the signature of every input of txn was made by the key of its owner,
+// the failures of all the inputs are returned in a SignaturesError
+func VerifySignatures(txn *Transaction, owners Owners) error {
+	if len(txn.Sigs) != len(txn.In) {
+		return fmt.Errorf("%d signatures for %d inputs", len(txn.Sigs), len(txn.In))
+	}
+
+	var errs SignaturesError
+	for i := range txn.In {
+		if err := verifySignature(txn, i, owners); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+func verifySignature(txn *Transaction, i int, owners Owners) *SignatureError {
+	in := txn.In[i]
+	owner, ok := owners[in]
+	if !ok {
+		return &SignatureError{Input: i, Hash: in, Err: fmt.Errorf("owner unknown")}
+	}
+
+	hash := txn.SignatureHash(i)
+	pubKey, err := cipher.PubKeyFromSig(txn.Sigs[i], hash)
+	if err != nil {
+		return &SignatureError{Input: i, Hash: in, Owner: owner, Err: err}
+	}
+	signer := cipher.AddressFromPubKey(pubKey)
+	if signer != owner {
+		return &SignatureError{Input: i, Hash: in, Owner: owner, Signer: signer}
+	}
+	if err := cipher.VerifyAddressSignedHash(owner, txn.Sigs[i], hash); err != nil {
+		return &SignatureError{Input: i, Hash: in, Owner: owner, Signer: signer, Err: err}
+	}
+	return nil
+}
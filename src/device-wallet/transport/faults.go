@@ -0,0 +1,318 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+)
+
+// ErrDisconnected is returned by a FaultConn once it injected a disconnection
+var ErrDisconnected = errors.New("transport: device disconnected")
+
+// FaultKind is a kind of fault injected in the reports exchanged with a device
+type FaultKind int
+
+const (
+	// FaultDrop the report is not written, or is skipped when read
+	FaultDrop FaultKind = iota + 1
+	// FaultDuplicate the report is written twice, or read twice
+	FaultDuplicate
+	// FaultTruncate only the beginning of the report is written or read
+	FaultTruncate
+	// FaultCorrupt the '?' marker or one of the '#' magic bytes of the report is altered
+	FaultCorrupt
+	// FaultDelay the report is delayed
+	FaultDelay
+	// FaultDisconnect the connection is closed, in the middle of a message if the report is not the last one
+	FaultDisconnect
+)
+
+func (k FaultKind) String() string {
+	switch k {
+	case FaultDrop:
+		return "drop"
+	case FaultDuplicate:
+		return "duplicate"
+	case FaultTruncate:
+		return "truncate"
+	case FaultCorrupt:
+		return "corrupt"
+	case FaultDelay:
+		return "delay"
+	case FaultDisconnect:
+		return "disconnect"
+	default:
+		return "invalid"
+	}
+}
+
+// Faults configures the faults injected, each rate is the probability of the fault for a report.
+// At most one fault is injected per report. The same Seed injects the same faults in the same exchange.
+type Faults struct {
+	Seed int64
+	// Direction the faults are injected in, both when zero
+	Direction Direction
+
+	Drop       float64
+	Duplicate  float64
+	Truncate   float64
+	Corrupt    float64
+	Delay      float64
+	Disconnect float64
+
+	// MaxDelay of a delayed report
+	MaxDelay time.Duration
+}
+
+// Fault is a fault injected
+type Fault struct {
+	Kind      FaultKind
+	Direction Direction
+	// Report is the index of the report among all the reports exchanged
+	Report int
+}
+
+// injector draws the faults, it is shared by the connections of a FaultDriver
+type injector struct {
+	mu       sync.Mutex
+	faults   Faults
+	rand     *rand.Rand
+	reports  int
+	injected []Fault
+}
+
+func newInjector(faults Faults) *injector {
+	return &injector{
+		faults: faults,
+		rand:   rand.New(rand.NewSource(faults.Seed)), // nolint: gosec
+	}
+}
+
+// draw returns the fault to inject in the next report, if any, and a random number to shape it
+func (inj *injector) draw(direction Direction) (FaultKind, int64) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	report := inj.reports
+	inj.reports++
+
+	// always draw the same amount of numbers, the faults of a report do not depend on the previous ones
+	x := inj.rand.Float64()
+	n := inj.rand.Int63()
+	if inj.faults.Direction != 0 && inj.faults.Direction != direction {
+		return 0, n
+	}
+
+	rates := []struct {
+		kind FaultKind
+		rate float64
+	}{
+		{FaultDrop, inj.faults.Drop},
+		{FaultDuplicate, inj.faults.Duplicate},
+		{FaultTruncate, inj.faults.Truncate},
+		{FaultCorrupt, inj.faults.Corrupt},
+		{FaultDelay, inj.faults.Delay},
+		{FaultDisconnect, inj.faults.Disconnect},
+	}
+	for _, r := range rates {
+		if x < r.rate {
+			inj.injected = append(inj.injected, Fault{
+				Kind:      r.kind,
+				Direction: direction,
+				Report:    report,
+			})
+			return r.kind, n
+		}
+		x -= r.rate
+	}
+	return 0, n
+}
+
+func (inj *injector) delay(n int64) {
+	if inj.faults.MaxDelay > 0 {
+		time.Sleep(time.Duration(n % int64(inj.faults.MaxDelay)))
+	}
+}
+
+func (inj *injector) injectedFaults() []Fault {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	return append([]Fault(nil), inj.injected...)
+}
+
+// corrupt returns a copy of report with its marker or one of its magic bytes altered
+func corrupt(report []byte, n int64) []byte {
+	corrupted := append([]byte(nil), report...)
+	i := 0
+	if len(corrupted) >= 3 && corrupted[0] == '?' && corrupted[1] == '#' && corrupted[2] == '#' {
+		i = int(n % 3)
+	}
+	if i < len(corrupted) {
+		corrupted[i] ^= 0xff
+	}
+	return corrupted
+}
+
+// FaultConn is a device connection injecting faults in the reports exchanged through it.
+// Dropping reports leaves the device or the host waiting for the rest of a message,
+// an operation with such a fault only ends with its context.
+type FaultConn struct {
+	dev      io.ReadWriteCloser
+	injector *injector
+
+	mu           sync.Mutex
+	disconnected bool
+	// duplicated is a report read to be read again
+	duplicated []byte
+}
+
+// NewFaultConn returns a connection to dev injecting faults
+func NewFaultConn(dev io.ReadWriteCloser, faults Faults) *FaultConn {
+	return &FaultConn{
+		dev:      dev,
+		injector: newInjector(faults),
+	}
+}
+
+// Injected returns the faults injected so far
+func (c *FaultConn) Injected() []Fault {
+	return c.injector.injectedFaults()
+}
+
+func (c *FaultConn) isDisconnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.disconnected
+}
+
+func (c *FaultConn) disconnect() error {
+	c.mu.Lock()
+	c.disconnected = true
+	c.mu.Unlock()
+	if err := c.dev.Close(); err != nil {
+		return err
+	}
+	return ErrDisconnected
+}
+
+// Write writes p to the device, possibly injecting a fault
+func (c *FaultConn) Write(p []byte) (int, error) {
+	if c.isDisconnected() {
+		return 0, ErrDisconnected
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	kind, n := c.injector.draw(Out)
+	switch kind {
+	case FaultDrop:
+		return len(p), nil
+	case FaultDuplicate:
+		if _, err := c.dev.Write(p); err != nil {
+			return 0, err
+		}
+	case FaultTruncate:
+		written, err := c.dev.Write(p[:int(n%int64(len(p)))])
+		if err != nil {
+			return written, err
+		}
+		return written, io.ErrShortWrite
+	case FaultCorrupt:
+		written, err := c.dev.Write(corrupt(p, n))
+		return written, err
+	case FaultDelay:
+		c.injector.delay(n)
+	case FaultDisconnect:
+		return 0, c.disconnect()
+	}
+	return c.dev.Write(p)
+}
+
+// Read reads a report from the device, possibly injecting a fault
+func (c *FaultConn) Read(p []byte) (int, error) {
+	if c.isDisconnected() {
+		return 0, ErrDisconnected
+	}
+
+	c.mu.Lock()
+	duplicated := c.duplicated
+	c.duplicated = nil
+	c.mu.Unlock()
+	if duplicated != nil {
+		return copy(p, duplicated), nil
+	}
+
+	kind, n := c.injector.draw(In)
+	if kind == FaultDisconnect {
+		return 0, c.disconnect()
+	}
+	if kind == FaultDelay {
+		c.injector.delay(n)
+	}
+
+	read, err := c.dev.Read(p)
+	if err != nil {
+		return read, err
+	}
+
+	switch kind {
+	case FaultDrop:
+		return c.dev.Read(p)
+	case FaultDuplicate:
+		c.mu.Lock()
+		c.duplicated = append([]byte(nil), p[:read]...)
+		c.mu.Unlock()
+	case FaultTruncate:
+		if read > 0 {
+			read = int(n % int64(read))
+		}
+	case FaultCorrupt:
+		copy(p, corrupt(p[:read], n))
+	}
+	return read, nil
+}
+
+// Close closes the connection to the device
+func (c *FaultConn) Close() error {
+	if c.isDisconnected() {
+		return nil
+	}
+	return c.dev.Close()
+}
+
+// FaultDriver is a DeviceDriver injecting faults in the reports exchanged on the connections of another driver.
+// The faults are drawn across all the connections, the same Seed injects the same faults in the same session.
+type FaultDriver struct {
+	deviceWallet.DeviceDriver
+	injector *injector
+}
+
+// NewFaultDriver returns a driver injecting faults in the connections of driver
+func NewFaultDriver(driver deviceWallet.DeviceDriver, faults Faults) *FaultDriver {
+	return &FaultDriver{
+		DeviceDriver: driver,
+		injector:     newInjector(faults),
+	}
+}
+
+// GetDevice returns a connection to the device of the wrapped driver injecting faults
+func (drv *FaultDriver) GetDevice() (io.ReadWriteCloser, error) {
+	dev, err := drv.DeviceDriver.GetDevice()
+	if err != nil {
+		return nil, err
+	}
+	return &FaultConn{
+		dev:      dev,
+		injector: drv.injector,
+	}, nil
+}
+
+// Injected returns the faults injected so far
+func (drv *FaultDriver) Injected() []Fault {
+	return drv.injector.injectedFaults()
+}
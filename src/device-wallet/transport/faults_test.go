@@ -0,0 +1,186 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+	messages "github.com/skycoin/hardware-wallet-go/src/device-wallet/messages/go"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/simulator"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/wire"
+)
+
+// loopback returns the reports written when read, io.EOF when there is none
+type loopback struct {
+	reports [][]byte
+}
+
+func (l *loopback) Write(p []byte) (int, error) {
+	l.reports = append(l.reports, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func (l *loopback) Read(p []byte) (int, error) {
+	if len(l.reports) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, l.reports[0])
+	l.reports = l.reports[1:]
+	return n, nil
+}
+
+func (l *loopback) Close() error {
+	return nil
+}
+
+// exchange writes and reads reports through a FaultConn and returns what happened
+func exchange(faults Faults) ([]string, []Fault) {
+	c := NewFaultConn(&loopback{}, faults)
+
+	var events []string
+	for i := 0; i < 200; i++ {
+		report := make([]byte, 64)
+		copy(report, "?##")
+		report[10] = byte(i)
+		n, err := c.Write(report)
+		events = append(events, fmt.Sprintf("write %d %v", n, err))
+
+		n, err = c.Read(report)
+		events = append(events, fmt.Sprintf("read %x %v", report[:n], err))
+	}
+	return events, c.Injected()
+}
+
+func TestFaultConnReproducible(t *testing.T) {
+	faults := Faults{
+		Seed:       42,
+		Drop:       0.05,
+		Duplicate:  0.05,
+		Truncate:   0.05,
+		Corrupt:    0.05,
+		Delay:      0.05,
+		Disconnect: 0.002,
+		MaxDelay:   time.Microsecond,
+	}
+
+	events, injected := exchange(faults)
+	kinds := make(map[FaultKind]bool)
+	for _, fault := range injected {
+		kinds[fault.Kind] = true
+	}
+	require.Len(t, kinds, 6)
+
+	again, injectedAgain := exchange(faults)
+	require.Equal(t, events, again)
+	require.Equal(t, injected, injectedAgain)
+
+	faults.Seed = 43
+	_, injectedOther := exchange(faults)
+	require.NotEqual(t, injected, injectedOther)
+
+	// faults in one direction only
+	faults.Direction = In
+	_, injected = exchange(faults)
+	for _, fault := range injected {
+		require.Equal(t, In, fault.Direction)
+	}
+}
+
+func TestFaultConn(t *testing.T) {
+	report := make([]byte, 64)
+	copy(report, "?##")
+
+	c := NewFaultConn(&loopback{}, Faults{Corrupt: 1, Direction: Out})
+	_, err := c.Write(report)
+	require.NoError(t, err)
+	read := make([]byte, 64)
+	_, err = c.Read(read)
+	require.NoError(t, err)
+	require.NotEqual(t, report, read)
+	require.Equal(t, report[3:], read[3:])
+
+	c = NewFaultConn(&loopback{}, Faults{Truncate: 1, Direction: Out})
+	n, err := c.Write(report)
+	require.Equal(t, io.ErrShortWrite, err)
+	require.True(t, n < len(report))
+
+	c = NewFaultConn(&loopback{}, Faults{Duplicate: 1, Direction: In})
+	_, err = c.Write(report)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		n, err = c.Read(read)
+		require.NoError(t, err)
+		require.Equal(t, report, read[:n])
+	}
+
+	c = NewFaultConn(&loopback{}, Faults{Disconnect: 1})
+	_, err = c.Write(report)
+	require.Equal(t, ErrDisconnected, err)
+	_, err = c.Read(read)
+	require.Equal(t, ErrDisconnected, err)
+	require.Equal(t, []Fault{{Kind: FaultDisconnect, Direction: Out}}, c.Injected())
+}
+
+func ping(device *deviceWallet.Device, message string) error {
+	data, err := proto.Marshal(&messages.Ping{Message: proto.String(message)})
+	if err != nil {
+		return err
+	}
+	msg, err := device.SendMessage(wire.Message{Kind: uint16(messages.MessageType_MessageType_Ping), Data: data})
+	if err != nil {
+		return err
+	}
+	answer, err := deviceWallet.DecodeSuccessMsg(msg)
+	if err != nil {
+		return err
+	}
+	if answer != message {
+		return fmt.Errorf("answer %q to ping %q", answer, message)
+	}
+	return nil
+}
+
+// TestFaultsRecovery checks a device gives either the right answer or an error while faults are injected,
+// the faults never make it read the answer to another message
+func TestFaultsRecovery(t *testing.T) {
+	for _, session := range []bool{false, true} {
+		t.Run(fmt.Sprintf("session %v", session), func(t *testing.T) {
+			driver := NewFaultDriver(simulator.NewDriver(simulator.New()), Faults{
+				Seed:       1,
+				Truncate:   0.05,
+				Corrupt:    0.05,
+				Delay:      0.05,
+				Disconnect: 0.05,
+				MaxDelay:   time.Millisecond,
+			})
+			device := &deviceWallet.Device{Driver: driver}
+			if session {
+				require.NoError(t, device.OpenSession())
+				defer device.CloseSession()
+			}
+
+			failures := 0
+			for i := 0; i < 100; i++ {
+				// a long message is sent and answered in several reports
+				err := ping(device, strconv.Itoa(i)+string(make([]byte, 100)))
+				if err != nil {
+					require.True(t,
+						errors.Is(err, wire.ErrMalformedMessage) || errors.Is(err, ErrDisconnected) ||
+							errors.Is(err, io.ErrShortWrite) || errors.Is(err, simulator.ErrClosed),
+						"unexpected error %v", err)
+					failures++
+				}
+			}
+			require.NotZero(t, failures)
+			require.NotEqual(t, 100, failures)
+			require.NotEmpty(t, driver.Injected())
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+)
+
+// Recorder is a device connection writing the reports exchanged through it to a transcript
+type Recorder struct {
+	io.ReadWriteCloser
+
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+// NewRecorder returns a connection to dev recording the reports to w, one transcript line per report.
+// The transcript header is not written, see NewRecordDriver.
+func NewRecorder(dev io.ReadWriteCloser, w io.Writer) *Recorder {
+	return &Recorder{
+		ReadWriteCloser: dev,
+		mu:              &sync.Mutex{},
+		w:               w,
+	}
+}
+
+func (r *Recorder) record(direction Direction, report []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err := fmt.Fprintln(r.w, Entry{
+		Time:      time.Now(),
+		Direction: direction,
+		Report:    report,
+	})
+	return err
+}
+
+// Write writes p to the device and records it
+func (r *Recorder) Write(p []byte) (int, error) {
+	n, err := r.ReadWriteCloser.Write(p)
+	if n > 0 {
+		if err := r.record(Out, p[:n]); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+// Read reads from the device and records what was read
+func (r *Recorder) Read(p []byte) (int, error) {
+	n, err := r.ReadWriteCloser.Read(p)
+	if n > 0 {
+		if err := r.record(In, p[:n]); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+// RecordDriver is a DeviceDriver recording the reports exchanged on the connections of another driver
+type RecordDriver struct {
+	deviceWallet.DeviceDriver
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewRecordDriver returns a driver recording the reports exchanged through driver to w.
+// The transcript header is written first, the transcript can be replayed with NewReplayDriver.
+func NewRecordDriver(driver deviceWallet.DeviceDriver, w io.Writer) (*RecordDriver, error) {
+	if _, err := (&Transcript{DeviceType: driver.DeviceType()}).WriteTo(w); err != nil {
+		return nil, err
+	}
+	return &RecordDriver{
+		DeviceDriver: driver,
+		w:            w,
+	}, nil
+}
+
+// GetDevice returns a recording connection to the device of the wrapped driver
+func (drv *RecordDriver) GetDevice() (io.ReadWriteCloser, error) {
+	dev, err := drv.DeviceDriver.GetDevice()
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{
+		ReadWriteCloser: dev,
+		mu:              &drv.mu,
+		w:               drv.w,
+	}, nil
+}
@@ -0,0 +1,153 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+)
+
+// ErrClosed is returned when using a closed replay connection
+var ErrClosed = errors.New("replay connection closed")
+
+// DivergenceError is returned when the reports exchanged differ from the replayed transcript
+type DivergenceError struct {
+	// Index of the transcript entry expected, len(Entries) past the end of the transcript
+	Index int
+	// Expected entry, nil past the end of the transcript
+	Expected *Entry
+	// Direction and Report of the exchange, Report is nil for a read
+	Direction Direction
+	Report    []byte
+}
+
+func (e DivergenceError) Error() string {
+	got := e.Direction.String()
+	if e.Direction == Out {
+		got = fmt.Sprintf("%s %x", got, e.Report)
+	}
+	if e.Expected == nil {
+		return fmt.Sprintf("transcript diverges at entry %d: expected end of transcript, got %s", e.Index, got)
+	}
+	return fmt.Sprintf("transcript diverges at entry %d: expected %s %x, got %s", e.Index, e.Expected.Direction, e.Expected.Report, got)
+}
+
+// ReplayDriver is a DeviceDriver serving a recorded transcript back.
+// The reports written must be the ones of the transcript, in the same order, or a DivergenceError is returned.
+// The reads return the reports read in the transcript. A transcript can span several connections.
+type ReplayDriver struct {
+	deviceWallet.Driver
+
+	transcript *Transcript
+
+	mu   sync.Mutex
+	next int
+	err  error
+}
+
+// NewReplayDriver returns a driver replaying transcript
+func NewReplayDriver(transcript *Transcript) *ReplayDriver {
+	return &ReplayDriver{
+		transcript: transcript,
+	}
+}
+
+// DeviceType returns the type of the recorded device
+func (drv *ReplayDriver) DeviceType() deviceWallet.DeviceType {
+	return drv.transcript.DeviceType
+}
+
+// GetDevice returns a new connection replaying the rest of the transcript
+func (drv *ReplayDriver) GetDevice() (io.ReadWriteCloser, error) {
+	return &replayConn{drv: drv}, nil
+}
+
+// Done returns the first divergence found, or a DivergenceError when the transcript was not replayed entirely
+func (drv *ReplayDriver) Done() error {
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+
+	if drv.err != nil {
+		return drv.err
+	}
+	if drv.next < len(drv.transcript.Entries) {
+		return fmt.Errorf("transcript replayed up to entry %d of %d", drv.next, len(drv.transcript.Entries))
+	}
+	return nil
+}
+
+// exchange checks the next entry of the transcript is in direction and, for a write, has the given report.
+// It returns the report of the entry.
+func (drv *ReplayDriver) exchange(direction Direction, report []byte) ([]byte, error) {
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+
+	// once diverged the replay cannot go on
+	if drv.err != nil {
+		return nil, drv.err
+	}
+
+	divergence := DivergenceError{
+		Index:     drv.next,
+		Direction: direction,
+		Report:    report,
+	}
+	if drv.next >= len(drv.transcript.Entries) {
+		drv.err = divergence
+		return nil, drv.err
+	}
+
+	entry := drv.transcript.Entries[drv.next]
+	if entry.Direction != direction || (direction == Out && string(entry.Report) != string(report)) {
+		divergence.Expected = &entry
+		drv.err = divergence
+		return nil, drv.err
+	}
+
+	drv.next++
+	return entry.Report, nil
+}
+
+// replayConn is a connection of a ReplayDriver
+type replayConn struct {
+	drv *ReplayDriver
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *replayConn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func (c *replayConn) Write(p []byte) (int, error) {
+	if c.isClosed() {
+		return 0, ErrClosed
+	}
+	if _, err := c.drv.exchange(Out, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	if c.isClosed() {
+		return 0, ErrClosed
+	}
+	report, err := c.drv.exchange(In, nil)
+	if err != nil {
+		return 0, err
+	}
+	return copy(p, report), nil
+}
+
+func (c *replayConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
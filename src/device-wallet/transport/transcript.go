@@ -0,0 +1,151 @@
+/*
+Package transport provides device connections wrapping others, to record and replay the reports
+exchanged with a device and to inject faults in them.
+*/
+package transport
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+)
+
+// Direction of a report
+type Direction int
+
+const (
+	// Out report written to the device
+	Out Direction = iota + 1
+	// In report read from the device
+	In
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Out:
+		return ">"
+	case In:
+		return "<"
+	default:
+		return "?"
+	}
+}
+
+// Entry is a report exchanged with the device
+type Entry struct {
+	Time      time.Time
+	Direction Direction
+	Report    []byte
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s %s %x", e.Time.UTC().Format(time.RFC3339Nano), e.Direction, e.Report)
+}
+
+// Transcript holds the reports exchanged with a device, in the order of the exchange.
+//
+// A transcript is stored as text, a header giving the device type followed by a line per report
+// with its time, its direction, ">" for written and "<" for read, and its hex encoding:
+//
+//	# device USB
+//	2019-04-05T10:00:00.000000001Z > 3f23230037000000...
+//	2019-04-05T10:00:00.000000002Z < 3f23230011000000...
+type Transcript struct {
+	DeviceType deviceWallet.DeviceType
+	Entries    []Entry
+}
+
+const headerPrefix = "# device "
+
+// ReadTranscript parses a transcript
+func ReadTranscript(r io.Reader) (*Transcript, error) {
+	transcript := &Transcript{}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, headerPrefix) {
+			transcript.DeviceType = deviceWallet.DeviceTypeFromString(strings.TrimPrefix(text, headerPrefix))
+			continue
+		}
+		if strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		entry, err := parseEntry(text)
+		if err != nil {
+			return nil, fmt.Errorf("transcript line %d: %v", line, err)
+		}
+		transcript.Entries = append(transcript.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if transcript.DeviceType != deviceWallet.DeviceTypeUSB && transcript.DeviceType != deviceWallet.DeviceTypeEmulator {
+		return nil, fmt.Errorf("transcript device type missing or invalid")
+	}
+	return transcript, nil
+}
+
+func parseEntry(text string) (Entry, error) {
+	fields := strings.Fields(text)
+	if len(fields) != 3 {
+		return Entry{}, fmt.Errorf("expected time, direction and report, got %d fields", len(fields))
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return Entry{}, err
+	}
+
+	var direction Direction
+	switch fields[1] {
+	case Out.String():
+		direction = Out
+	case In.String():
+		direction = In
+	default:
+		return Entry{}, fmt.Errorf("invalid direction %q", fields[1])
+	}
+
+	report, err := hex.DecodeString(fields[2])
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return Entry{
+		Time:      t,
+		Direction: direction,
+		Report:    report,
+	}, nil
+}
+
+// WriteTo writes the transcript in its text format
+func (t *Transcript) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	n, err := fmt.Fprintf(w, "%s%s\n", headerPrefix, t.DeviceType)
+	written += int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	for _, entry := range t.Entries {
+		n, err := fmt.Fprintln(w, entry)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	deviceWallet "github.com/skycoin/hardware-wallet-go/src/device-wallet"
+	"github.com/skycoin/hardware-wallet-go/src/device-wallet/simulator"
+)
+
+const testMnemonic = "cloud flower upset remain green metal below cup stem infant art thank"
+
+// session runs a few operations on device and returns the addresses generated
+func session(device *deviceWallet.Device, mnemonic string) ([]string, error) {
+	if _, err := device.SetMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	return deviceWallet.NewClient(device).AddressGen(2, 0, false)
+}
+
+func TestReadTranscript(t *testing.T) {
+	transcript, err := ReadTranscript(strings.NewReader(`# device EMULATOR
+# comment
+2019-04-05T10:00:00.000000001Z > 3f2323
+
+2019-04-05T10:00:00.5Z < 3f
+`))
+	require.NoError(t, err)
+	require.Equal(t, &Transcript{
+		DeviceType: deviceWallet.DeviceTypeEmulator,
+		Entries: []Entry{
+			{Time: time.Date(2019, 4, 5, 10, 0, 0, 1, time.UTC), Direction: Out, Report: []byte("?##")},
+			{Time: time.Date(2019, 4, 5, 10, 0, 0, 500000000, time.UTC), Direction: In, Report: []byte("?")},
+		},
+	}, transcript)
+
+	var buf bytes.Buffer
+	_, err = transcript.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, `# device EMULATOR
+2019-04-05T10:00:00.000000001Z > 3f2323
+2019-04-05T10:00:00.5Z < 3f
+`, buf.String())
+
+	_, err = ReadTranscript(strings.NewReader("2019-04-05T10:00:00Z > 3f\n"))
+	require.EqualError(t, err, "transcript device type missing or invalid")
+	_, err = ReadTranscript(strings.NewReader("# device USB\n2019-04-05T10:00:00Z = 3f\n"))
+	require.EqualError(t, err, `transcript line 2: invalid direction "="`)
+	_, err = ReadTranscript(strings.NewReader("# device USB\n2019-04-05T10:00:00Z > 3f 00\n"))
+	require.EqualError(t, err, "transcript line 2: expected time, direction and report, got 4 fields")
+}
+
+func TestRecordReplay(t *testing.T) {
+	var buf bytes.Buffer
+	recordDriver, err := NewRecordDriver(simulator.NewDriver(simulator.New()), &buf)
+	require.NoError(t, err)
+	recorded, err := session(&deviceWallet.Device{Driver: recordDriver}, testMnemonic)
+	require.NoError(t, err)
+
+	transcript, err := ReadTranscript(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, deviceWallet.DeviceTypeEmulator, transcript.DeviceType)
+	require.NotEmpty(t, transcript.Entries)
+	require.Equal(t, Out, transcript.Entries[0].Direction)
+
+	replayDriver := NewReplayDriver(transcript)
+	replayed, err := session(&deviceWallet.Device{Driver: replayDriver}, testMnemonic)
+	require.NoError(t, err)
+	require.Equal(t, recorded, replayed)
+	require.NoError(t, replayDriver.Done())
+
+	// another mnemonic diverges from the transcript
+	replayDriver = NewReplayDriver(transcript)
+	_, err = session(&deviceWallet.Device{Driver: replayDriver}, "all all all all all all all all all all all all")
+	var divergence DivergenceError
+	require.True(t, errors.As(err, &divergence))
+	require.Equal(t, 0, divergence.Index)
+	require.Equal(t, Out, divergence.Expected.Direction)
+	require.Equal(t, err, replayDriver.Done())
+
+	// an unfinished replay
+	replayDriver = NewReplayDriver(transcript)
+	device := &deviceWallet.Device{Driver: replayDriver}
+	_, err = device.SetMnemonic(testMnemonic)
+	require.NoError(t, err)
+	require.Error(t, replayDriver.Done())
+
+	// going past the end
+	_, err = deviceWallet.NewClient(device).AddressGen(2, 0, false)
+	require.NoError(t, err)
+	require.NoError(t, replayDriver.Done())
+	_, err = device.GetFeatures()
+	require.True(t, errors.As(err, &divergence))
+	require.Nil(t, divergence.Expected)
+	require.Equal(t, len(transcript.Entries), divergence.Index)
+}
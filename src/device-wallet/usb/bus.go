@@ -23,6 +23,7 @@ type Info struct {
 	Path      string
 	VendorID  int
 	ProductID int
+	Serial    string // USB serial number, empty when unknown
 }
 
 type Device interface {
@@ -31,6 +31,7 @@ func (b *HIDAPI) Enumerate() ([]Info, error) {
 				Path:      b.identify(&dev),
 				VendorID:  int(dev.VendorID),
 				ProductID: int(dev.ProductID),
+				Serial:    dev.Serial,
 			})
 		}
 	}
@@ -19,7 +19,10 @@ const (
 	emulatorPingTimeout = 700 * time.Millisecond
 )
 
+// UDP is the bus of the emulators listening on the given ports of a host.
+// The path of an emulator is "emulator" followed by its port.
 type UDP struct {
+	host  string
 	ports []int
 
 	pings   map[int](chan []byte)
@@ -50,8 +53,13 @@ func listen(conn net.Conn) (chan []byte, chan []byte) {
 	return ping, data
 }
 
-func InitUDP(ports []int) (*UDP, error) {
+// InitUDP returns the bus of the emulators listening on ports of host, 127.0.0.1 when empty
+func InitUDP(host string, ports []int) (*UDP, error) {
+	if host == "" {
+		host = emulatorAddress
+	}
 	udp := UDP{
+		host:  host,
 		ports: ports,
 
 		pings:   make(map[int](chan []byte)),
@@ -59,7 +67,7 @@ func InitUDP(ports []int) (*UDP, error) {
 		writers: make(map[int](io.Writer)),
 	}
 	for _, port := range ports {
-		address := emulatorAddress + ":" + strconv.Itoa(port)
+		address := net.JoinHostPort(host, strconv.Itoa(port))
 
 		connection, err := net.Dial("udp", address)
 		if err != nil {
@@ -117,6 +125,9 @@ func (u *UDP) Connect(path string) (Device, error) {
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := u.writers[i]; !ok {
+		return nil, ErrNotFound
+	}
 	return &UDPDevice{
 		ping:   u.pings[i],
 		data:   u.datas[i],
@@ -0,0 +1,74 @@
+package usb
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmulator answers the pings and echoes the reports it receives
+func fakeEmulator(t *testing.T) (int, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			reply := buf[:n]
+			if bytes.Equal(reply, emulatorPing) {
+				reply = emulatorPong
+			}
+			if _, err := conn.WriteTo(reply, addr); err != nil {
+				return
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).Port, func() {
+		conn.Close()
+	}
+}
+
+// unusedPort returns a port nobody listens on
+func unusedPort(t *testing.T) int {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestUDP(t *testing.T) {
+	port, stop := fakeEmulator(t)
+	defer stop()
+	absent := unusedPort(t)
+
+	udp, err := InitUDP("localhost", []int{absent, port})
+	require.NoError(t, err)
+
+	infos, err := Init(udp).Enumerate()
+	require.NoError(t, err)
+	require.Equal(t, []Info{{Path: emulatorPrefix + strconv.Itoa(port)}}, infos)
+
+	dev, err := Init(udp).Connect(infos[0].Path)
+	require.NoError(t, err)
+	report := make([]byte, 64)
+	copy(report, "?##")
+	_, err = dev.Write(report)
+	require.NoError(t, err)
+	answer := make([]byte, 64)
+	n, err := dev.Read(answer)
+	require.NoError(t, err)
+	require.Equal(t, report, answer[:n])
+	require.NoError(t, dev.Close())
+	_, err = dev.Read(answer)
+	require.Equal(t, closedDeviceError, err)
+
+	_, err = udp.Connect(emulatorPrefix + "1")
+	require.Equal(t, ErrNotFound, err)
+}
@@ -0,0 +1,102 @@
+package usb
+
+import (
+	"context"
+	"time"
+)
+
+// watchInterval is the delay between two enumerations of the buses by Watch
+var watchInterval = 500 * time.Millisecond
+
+// EventType tells whether a device arrived or left
+type EventType int
+
+const (
+	// EventArrive a device was attached
+	EventArrive EventType = iota + 1
+	// EventLeave a device was detached
+	EventLeave
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventArrive:
+		return "arrive"
+	case EventLeave:
+		return "leave"
+	default:
+		return "invalid"
+	}
+}
+
+// Event is emitted by Watch when a device arrives or leaves
+type Event struct {
+	Type EventType
+	Info Info
+}
+
+// Bootloader reports whether the device is running its bootloader rather than the firmware,
+// according to its product id
+func (i Info) Bootloader() bool {
+	return (i.VendorID == vendorT1 && i.ProductID == productT1Bootloader) ||
+		(i.VendorID == vendorT2 && i.ProductID == productT2Bootloader)
+}
+
+// Watch emits an arrive event for every device attached to the buses, then an event each time
+// a device arrives or leaves, until ctx is done and the channel is closed.
+// The buses are enumerated periodically. A device restarting in bootloader mode leaves
+// and arrives again with another product id.
+func (b *USB) Watch(ctx context.Context) (<-chan Event, error) {
+	infos, err := b.Enumerate()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+
+		known := make(map[Info]bool)
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		for {
+			current := make(map[Info]bool, len(infos))
+			for _, info := range infos {
+				current[info] = true
+			}
+
+			var changes []Event
+			for info := range known {
+				if !current[info] {
+					changes = append(changes, Event{Type: EventLeave, Info: info})
+				}
+			}
+			for _, info := range infos {
+				if !known[info] {
+					changes = append(changes, Event{Type: EventArrive, Info: info})
+				}
+			}
+			known = current
+
+			for _, event := range changes {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			// a failing enumeration keeps the known devices until the next one
+			if l, err := b.Enumerate(); err == nil {
+				infos = l
+			}
+		}
+	}()
+	return events, nil
+}
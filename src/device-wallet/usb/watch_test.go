@@ -0,0 +1,97 @@
+package usb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBus is a Bus whose devices are set by the test
+type fakeBus struct {
+	mu    sync.Mutex
+	infos []Info
+	err   error
+}
+
+func (b *fakeBus) set(err error, infos ...Info) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.infos = infos
+	b.err = err
+}
+
+func (b *fakeBus) Enumerate() ([]Info, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.infos, b.err
+}
+
+func (b *fakeBus) Connect(path string) (Device, error) {
+	return nil, ErrNotFound
+}
+
+func (b *fakeBus) Has(path string) bool {
+	return false
+}
+
+func requireEvent(t *testing.T, events <-chan Event, expected Event) {
+	select {
+	case event := <-events:
+		require.Equal(t, expected, event)
+	case <-time.After(time.Second):
+		t.Fatalf("no %s event for %s", expected.Type, expected.Info.Path)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	defer func(interval time.Duration) {
+		watchInterval = interval
+	}(watchInterval)
+	watchInterval = time.Millisecond
+
+	firmware := Info{Path: "web01", VendorID: vendorT1, ProductID: productT1Firmware}
+	bootloader := Info{Path: "web01", VendorID: vendorT1, ProductID: productT1Bootloader}
+	other := Info{Path: "hid02", VendorID: vendorT2, ProductID: productT2Firmware}
+	require.False(t, firmware.Bootloader())
+	require.True(t, bootloader.Bootloader())
+	require.False(t, other.Bootloader())
+
+	bus := &fakeBus{}
+	bus.set(nil, firmware)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := Init(bus).Watch(ctx)
+	require.NoError(t, err)
+
+	requireEvent(t, events, Event{Type: EventArrive, Info: firmware})
+
+	bus.set(nil, firmware, other)
+	requireEvent(t, events, Event{Type: EventArrive, Info: other})
+
+	// enumeration errors do not make the devices leave
+	bus.set(errors.New("busy"))
+	time.Sleep(10 * time.Millisecond)
+
+	// restarted in bootloader mode
+	bus.set(nil, other, bootloader)
+	requireEvent(t, events, Event{Type: EventLeave, Info: firmware})
+	requireEvent(t, events, Event{Type: EventArrive, Info: bootloader})
+
+	bus.set(nil, bootloader)
+	requireEvent(t, events, Event{Type: EventLeave, Info: other})
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatchEnumerateError(t *testing.T) {
+	bus := &fakeBus{}
+	bus.set(errors.New("busy"))
+	_, err := Init(bus).Watch(context.Background())
+	require.EqualError(t, err, "busy")
+}
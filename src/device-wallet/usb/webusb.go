@@ -21,6 +21,19 @@ const (
 
 type WebUSB struct {
 	usb usbhid.Context
+
+	// serials caches the serial numbers read by Enumerate, so that the devices are not opened again
+	// at every enumeration, e.g. by Watch
+	serialsMu sync.Mutex
+	serials   map[serialKey]string
+}
+
+// serialKey identifies a device attached to a port, a device restarting in bootloader mode
+// keeps its port but changes product id
+type serialKey struct {
+	path      string
+	vendorID  uint16
+	productID uint16
 }
 
 func InitWebUSB() (*WebUSB, error) {
@@ -32,7 +45,8 @@ func InitWebUSB() (*WebUSB, error) {
 	usbhid.Set_Debug(usb, usbhid.LOG_LEVEL_NONE)
 
 	return &WebUSB{
-		usb: usb,
+		usb:     usb,
+		serials: make(map[serialKey]string),
 	}, nil
 }
 
@@ -53,6 +67,10 @@ func (b *WebUSB) Enumerate() ([]Info, error) {
 	// device appear twice with the same path
 	paths := make(map[string]bool)
 
+	b.serialsMu.Lock()
+	defer b.serialsMu.Unlock()
+	attached := make(map[serialKey]bool)
+
 	for _, dev := range list {
 		if b.match(dev) {
 			dd, err := usbhid.Get_Device_Descriptor(dev)
@@ -62,15 +80,31 @@ func (b *WebUSB) Enumerate() ([]Info, error) {
 			path := b.identify(dev)
 			inset := paths[path]
 			if !inset {
+				key := serialKey{path: path, vendorID: dd.IdVendor, productID: dd.IdProduct}
+				serial, ok := b.serials[key]
+				if !ok {
+					serial = b.serial(dev, dd)
+					b.serials[key] = serial
+				}
+				attached[key] = true
+
 				infos = append(infos, Info{
 					Path:      path,
 					VendorID:  int(dd.IdVendor),
 					ProductID: int(dd.IdProduct),
+					Serial:    serial,
 				})
 				paths[path] = true
 			}
 		}
 	}
+
+	// the serial of a device detached is read again when it is attached
+	for key := range b.serials {
+		if !attached[key] {
+			delete(b.serials, key)
+		}
+	}
 	return infos, nil
 }
 
@@ -156,6 +190,26 @@ func (b *WebUSB) match(dev usbhid.Device) bool {
 		c.Interface[webIfaceNum].Altsetting[webAltSetting].BInterfaceClass == usbhid.CLASS_VENDOR_SPEC)
 }
 
+// serial reads the serial number of the device, it is empty when the device cannot be opened.
+// It is read once per attachment, an empty serial is kept until the device is detached.
+func (b *WebUSB) serial(dev usbhid.Device, dd *usbhid.Device_Descriptor) string {
+	if dd.ISerialNumber == 0 {
+		return ""
+	}
+	d, err := usbhid.Open(dev)
+	if err != nil {
+		return ""
+	}
+	defer usbhid.Close(d)
+
+	var buf [256]byte
+	serial, err := usbhid.Get_String_Descriptor_ASCII(d, dd.ISerialNumber, buf[:])
+	if err != nil {
+		return ""
+	}
+	return string(serial)
+}
+
 func (b *WebUSB) identify(dev usbhid.Device) string {
 	var ports [8]byte
 	p, err := usbhid.Get_Port_Numbers(dev, ports[:])
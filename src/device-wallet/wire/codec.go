@@ -0,0 +1,212 @@
+package wire
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	// ReportSize is the size of the reports exchanged with the devices
+	ReportSize = 64
+	// DefaultMaxSize is the default maximum size of the payload of a message
+	DefaultMaxSize = 4 * 1024 * 1024
+
+	// headerLen is the length of the header of the first report of a message, the marker included
+	headerLen = 9
+)
+
+var (
+	// ErrMessageTooLarge is returned when the payload of a message exceeds the maximum size
+	ErrMessageTooLarge = errors.New("message too large")
+	// ErrReportSize is returned when the report size can not hold the header of a message
+	ErrReportSize = errors.New("invalid report size")
+)
+
+// Encoder writes messages to a device, one report per Write.
+//
+// The first report holds the '?' marker, the "##" magic, the kind and the size of the payload as big endian
+// uint16 and uint32, then the beginning of the payload. The next reports hold the '?' marker and the rest of the payload.
+// The last report is padded with zeros.
+type Encoder struct {
+	w          io.Writer
+	reportSize int
+	maxSize    int
+}
+
+// NewEncoder returns an encoder writing reports of reportSize bytes to w
+func NewEncoder(w io.Writer, reportSize int) *Encoder {
+	return &Encoder{
+		w:          w,
+		reportSize: reportSize,
+		maxSize:    DefaultMaxSize,
+	}
+}
+
+// SetMaxSize sets the maximum size of the payload of the messages encoded
+func (e *Encoder) SetMaxSize(maxSize int) {
+	e.maxSize = maxSize
+}
+
+// Encode writes the reports of m
+func (e *Encoder) Encode(m Message) error {
+	if e.reportSize <= headerLen {
+		return ErrReportSize
+	}
+	if len(m.Data) > e.maxSize {
+		return ErrMessageTooLarge
+	}
+
+	rep := make([]byte, e.reportSize)
+	rep[0] = repMarker
+	rep[1] = repMagic
+	rep[2] = repMagic
+	binary.BigEndian.PutUint16(rep[3:], m.Kind)
+	binary.BigEndian.PutUint32(rep[5:], uint32(len(m.Data)))
+
+	data := m.Data
+	offset := headerLen
+	for {
+		n := copy(rep[offset:], data)
+		data = data[n:]
+		for i := offset + n; i < len(rep); i++ {
+			rep[i] = 0x00
+		}
+		if _, err := e.w.Write(rep); err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			return nil
+		}
+		offset = 1 // just after the marker
+	}
+}
+
+// Decoder reads messages from the reports of a device.
+// The reports can be read from a reader by Decode or given one at a time to Feed.
+//
+// The size announced by the header of a message is checked against the limit of its kind
+// before the payload is read, and the payload can be checked with Validate,
+// a message refused is a ValidationError.
+type Decoder struct {
+	r          io.Reader
+	reportSize int
+	limits     Limits
+	validate   bool
+
+	// msg is the message being decoded, nil before its first report
+	msg  *Message
+	size int
+}
+
+// NewDecoder returns a decoder reading reports of reportSize bytes from r, r can be nil when only Feed is used
+func NewDecoder(r io.Reader, reportSize int) *Decoder {
+	return &Decoder{
+		r:          r,
+		reportSize: reportSize,
+	}
+}
+
+// SetMaxSize sets the maximum size of the payload of the messages decoded, whatever their kind
+func (d *Decoder) SetMaxSize(maxSize int) {
+	d.limits = Limits{Default: maxSize}
+}
+
+// SetLimits sets the maximum size of the payload of the messages decoded, per kind
+func (d *Decoder) SetLimits(limits Limits) {
+	d.limits = limits
+}
+
+// SetValidate sets whether the payloads decoded are checked with Validate
+func (d *Decoder) SetValidate(validate bool) {
+	d.validate = validate
+}
+
+// Decode reads reports until a whole message is decoded into m
+func (d *Decoder) Decode(m *Message) error {
+	if d.reportSize <= headerLen {
+		return ErrReportSize
+	}
+
+	rep := make([]byte, d.reportSize)
+	for {
+		n, err := d.r.Read(rep)
+		if err != nil {
+			d.Reset()
+			return err
+		}
+		msg, err := d.Feed(rep[:n])
+		if err != nil {
+			return err
+		}
+		if msg != nil {
+			*m = *msg
+			return nil
+		}
+	}
+}
+
+// Feed decodes a report, it returns the message once its last report is given and nil before.
+// A report which is not part of a message is an ErrMalformedMessage, the message being decoded is then dropped.
+func (d *Decoder) Feed(report []byte) (*Message, error) {
+	if d.reportSize <= headerLen {
+		return nil, ErrReportSize
+	}
+	if len(report) < d.reportSize {
+		d.Reset()
+		return nil, ErrMalformedMessage
+	}
+	report = report[:d.reportSize]
+
+	if d.msg == nil {
+		if report[0] != repMarker || report[1] != repMagic || report[2] != repMagic {
+			return nil, ErrMalformedMessage
+		}
+		kind := binary.BigEndian.Uint16(report[3:])
+		size := binary.BigEndian.Uint32(report[5:])
+		if limit := d.limits.Max(kind); uint64(size) > uint64(limit) {
+			return nil, ValidationError{
+				Kind:  kind,
+				Size:  int(size),
+				Limit: limit,
+				Err:   ErrMessageTooLarge,
+			}
+		}
+		d.size = int(size)
+		d.msg = &Message{
+			Kind: kind,
+			Data: make([]byte, 0, d.size),
+		}
+		d.msg.Data = append(d.msg.Data, report[headerLen:]...)
+	} else {
+		if report[0] != repMarker {
+			d.Reset()
+			return nil, ErrMalformedMessage
+		}
+		d.msg.Data = append(d.msg.Data, report[1:]...)
+	}
+
+	if len(d.msg.Data) < d.size {
+		return nil, nil
+	}
+	msg := d.msg
+	msg.Data = msg.Data[:d.size]
+	d.Reset()
+	if d.validate {
+		if err := Validate(msg.Data); err != nil {
+			return nil, ValidationError{
+				Kind:  msg.Kind,
+				Size:  len(msg.Data),
+				Limit: d.limits.Max(msg.Kind),
+				Err:   err,
+			}
+		}
+	}
+	return msg, nil
+}
+
+// Reset drops the message being decoded
+func (d *Decoder) Reset() {
+	d.msg = nil
+	d.size = 0
+}
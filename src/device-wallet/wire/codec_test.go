@@ -0,0 +1,232 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// reportBuffer keeps the reports written and reads them back one at a time
+type reportBuffer struct {
+	reports [][]byte
+}
+
+func (b *reportBuffer) Write(p []byte) (int, error) {
+	b.reports = append(b.reports, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func (b *reportBuffer) Read(p []byte) (int, error) {
+	if len(b.reports) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, b.reports[0])
+	b.reports = b.reports[1:]
+	return n, nil
+}
+
+func TestEncoder(t *testing.T) {
+	var buf reportBuffer
+	require.NoError(t, NewEncoder(&buf, ReportSize).Encode(Message{Kind: 1, Data: []byte{0x0a, 0x02}}))
+	require.Len(t, buf.reports, 1)
+	expected := make([]byte, ReportSize)
+	copy(expected, []byte("?##\x00\x01\x00\x00\x00\x02\x0a\x02"))
+	require.Equal(t, hex.EncodeToString(expected), hex.EncodeToString(buf.reports[0]))
+
+	// the payload fills the first report and the second one exactly
+	buf = reportBuffer{}
+	data := bytes.Repeat([]byte{0xff}, ReportSize-headerLen+ReportSize-1)
+	require.NoError(t, NewEncoder(&buf, ReportSize).Encode(Message{Kind: 2, Data: data}))
+	require.Len(t, buf.reports, 2)
+	require.Equal(t, byte('?'), buf.reports[1][0])
+	require.Equal(t, byte(0xff), buf.reports[1][ReportSize-1])
+
+	// an empty payload is sent in a header report
+	buf = reportBuffer{}
+	require.NoError(t, NewEncoder(&buf, ReportSize).Encode(Message{Kind: 3}))
+	require.Len(t, buf.reports, 1)
+
+	require.Equal(t, ErrReportSize, NewEncoder(&buf, headerLen).Encode(Message{}))
+
+	encoder := NewEncoder(&buf, ReportSize)
+	encoder.SetMaxSize(10)
+	require.Equal(t, ErrMessageTooLarge, encoder.Encode(Message{Data: make([]byte, 11)}))
+	require.NoError(t, encoder.Encode(Message{Data: make([]byte, 10)}))
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, reportSize := range []int{headerLen + 1, 16, ReportSize, 65, 1024} {
+		for size := 0; size < 300; size++ {
+			data := make([]byte, size)
+			for i := range data {
+				data[i] = byte(i*7 + size)
+			}
+			msg := Message{Kind: uint16(size), Data: data}
+
+			var buf reportBuffer
+			require.NoError(t, NewEncoder(&buf, reportSize).Encode(msg))
+			for _, report := range buf.reports {
+				require.Len(t, report, reportSize)
+			}
+
+			var decoded Message
+			require.NoError(t, NewDecoder(&buf, reportSize).Decode(&decoded))
+			require.Equal(t, msg.Kind, decoded.Kind)
+			require.Equal(t, msg.Data, decoded.Data)
+			require.Empty(t, buf.reports, "report size %d, payload size %d", reportSize, size)
+		}
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	encode := func(msg Message) [][]byte {
+		var buf reportBuffer
+		require.NoError(t, NewEncoder(&buf, ReportSize).Encode(msg))
+		return buf.reports
+	}
+	long := encode(Message{Kind: 1, Data: make([]byte, 100)})
+	short := encode(Message{Kind: 2, Data: []byte{1}})
+
+	cases := []struct {
+		name    string
+		reports [][]byte
+		err     error
+	}{
+		{
+			name:    "missing magic",
+			reports: [][]byte{append([]byte("?#!"), long[0][3:]...)},
+			err:     ErrMalformedMessage,
+		},
+		{
+			name:    "missing marker in a continuation",
+			reports: [][]byte{long[0], append([]byte("!"), long[1][1:]...)},
+			err:     ErrMalformedMessage,
+		},
+		{
+			name:    "short report",
+			reports: [][]byte{long[0][:ReportSize-1]},
+			err:     ErrMalformedMessage,
+		},
+		{
+			name:    "truncated message",
+			reports: [][]byte{long[0]},
+			err:     io.EOF,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var msg Message
+			err := NewDecoder(&reportBuffer{reports: tc.reports}, ReportSize).Decode(&msg)
+			require.Equal(t, tc.err, err)
+		})
+	}
+
+	// the size is checked before the payload is read
+	decoder := NewDecoder(&reportBuffer{reports: long}, ReportSize)
+	decoder.SetMaxSize(99)
+	var msg Message
+	err := decoder.Decode(&msg)
+	require.True(t, errors.Is(err, ErrMessageTooLarge))
+	require.Equal(t, ValidationError{Kind: 1, Size: 100, Limit: 99, Err: ErrMessageTooLarge}, err)
+
+	// a malformed report drops the message being decoded, the next message is decoded
+	decoder = NewDecoder(nil, ReportSize)
+	decoded, err := decoder.Feed(long[0])
+	require.NoError(t, err)
+	require.Nil(t, decoded)
+	_, err = decoder.Feed(long[1][:10])
+	require.Equal(t, ErrMalformedMessage, err)
+	decoded, err = decoder.Feed(short[0])
+	require.NoError(t, err)
+	require.Equal(t, &Message{Kind: 2, Data: []byte{1}}, decoded)
+
+	// Reset drops the message being decoded
+	_, err = decoder.Feed(long[0])
+	require.NoError(t, err)
+	decoder.Reset()
+	decoded, err = decoder.Feed(short[0])
+	require.NoError(t, err)
+	require.Equal(t, uint16(2), decoded.Kind)
+}
+
+func TestMessageWriteToReadFrom(t *testing.T) {
+	msg := Message{Kind: 17, Data: bytes.Repeat([]byte{1, 2, 3}, 50)}
+	var buf reportBuffer
+	n, err := msg.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(3*ReportSize), n)
+
+	var read Message
+	n, err = read.ReadFrom(&buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(3*ReportSize), n)
+	require.Equal(t, msg, read)
+}
+
+func TestDecoderLimits(t *testing.T) {
+	var buf reportBuffer
+	encoder := NewEncoder(&buf, ReportSize)
+	// a 4GB payload is announced, a single report is sent
+	require.NoError(t, encoder.Encode(Message{Kind: 3, Data: []byte{0x08, 0x01}}))
+	binary.BigEndian.PutUint32(buf.reports[0][5:], 0xffffffff)
+	require.NoError(t, encoder.Encode(Message{Kind: 1, Data: bytes.Repeat([]byte{0x08, 0x01}, 100)}))
+	require.NoError(t, encoder.Encode(Message{Kind: 2, Data: bytes.Repeat([]byte{0x08, 0x01}, 100)}))
+	// a length-delimited field exceeding the payload
+	require.NoError(t, encoder.Encode(Message{Kind: 2, Data: []byte{0x0a, 0x05, 0x01}}))
+
+	decoder := NewDecoder(&buf, ReportSize)
+	decoder.SetLimits(Limits{
+		Default: 150,
+		Kinds:   map[uint16]int{2: 1000},
+	})
+	decoder.SetValidate(true)
+
+	var msg Message
+	err := decoder.Decode(&msg)
+	require.Equal(t, ValidationError{Kind: 3, Size: 0xffffffff, Limit: 150, Err: ErrMessageTooLarge}, err)
+	err = decoder.Decode(&msg)
+	require.Equal(t, ValidationError{Kind: 1, Size: 200, Limit: 150, Err: ErrMessageTooLarge}, err)
+	require.EqualError(t, err, "invalid message of kind 1: payload of 200 bytes exceeds the limit of 150 bytes")
+
+	// the reports of the refused message are not part of a message
+	for {
+		err = decoder.Decode(&msg)
+		if err != ErrMalformedMessage {
+			break
+		}
+	}
+	require.NoError(t, err)
+	require.Equal(t, uint16(2), msg.Kind)
+	require.Len(t, msg.Data, 200)
+
+	err = decoder.Decode(&msg)
+	require.True(t, errors.Is(err, ErrMalformedProtobuf))
+	require.Equal(t, ValidationError{Kind: 2, Size: 3, Limit: 1000, Err: ErrMalformedProtobuf}, err)
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "empty"},
+		{name: "varint", data: []byte{0x08, 0x96, 0x01}},
+		{name: "string", data: []byte{0x12, 0x02, 'o', 'k'}},
+		{name: "fixed64", data: []byte{0x09, 0, 0, 0, 0, 0, 0, 0, 0}, err: ErrMalformedProtobuf},
+		{name: "field number zero", data: []byte{0x00, 0x01}, err: ErrMalformedProtobuf},
+		{name: "truncated varint", data: []byte{0x08, 0x96}, err: ErrMalformedProtobuf},
+		{name: "missing value", data: []byte{0x08}, err: ErrMalformedProtobuf},
+		{name: "truncated string", data: []byte{0x12, 0x03, 'o', 'k'}, err: ErrMalformedProtobuf},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.err, Validate(tc.data))
+		})
+	}
+}
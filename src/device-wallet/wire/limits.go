@@ -0,0 +1,45 @@
+package wire
+
+import "fmt"
+
+// Limits are the maximum payload sizes of the messages decoded, per message kind
+type Limits struct {
+	// Default is the maximum size of the kinds missing from Kinds, DefaultMaxSize when zero
+	Default int
+	Kinds   map[uint16]int
+}
+
+// Max returns the maximum payload size of the messages of kind
+func (l Limits) Max(kind uint16) int {
+	if max, ok := l.Kinds[kind]; ok {
+		return max
+	}
+	if l.Default > 0 {
+		return l.Default
+	}
+	return DefaultMaxSize
+}
+
+// ValidationError is returned when a message decoded is refused, because its size exceeds
+// the limit of its kind or its payload is not valid protobuf
+type ValidationError struct {
+	Kind uint16
+	// Size of the payload announced by the message header
+	Size int
+	// Limit of the payload size of the kind
+	Limit int
+	// Err is ErrMessageTooLarge or the error of Validate
+	Err error
+}
+
+func (e ValidationError) Error() string {
+	if e.Err == ErrMessageTooLarge {
+		return fmt.Sprintf("invalid message of kind %d: payload of %d bytes exceeds the limit of %d bytes", e.Kind, e.Size, e.Limit)
+	}
+	return fmt.Sprintf("invalid message of kind %d: %v", e.Kind, e.Err)
+}
+
+// Unwrap returns the cause of the error
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
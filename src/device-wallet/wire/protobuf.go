@@ -11,6 +11,8 @@ var (
 	ErrMalformedProtobuf = errors.New("malformed protobuf")
 )
 
+// Validate checks buf is a protobuf message made of varint and length-delimited fields,
+// the lengths not exceeding the message
 func Validate(buf []byte) error {
 	const (
 		wireVarint   = 0               // int32, int64, uint32, uint64, sint32, sint64, bool, enum
@@ -24,23 +26,23 @@ func Validate(buf []byte) error {
 		// read the field key (combination of tag and type)
 		key, err := binary.ReadUvarint(r)
 		if err != nil {
-			return err
+			return ErrMalformedProtobuf
 		}
 
-		// validate the field type
+		// validate the field type and number
 		typ := key & 7
-		if typ != wireVarint && typ != wireData {
+		if typ != wireVarint && typ != wireData || key>>3 == 0 {
 			return ErrMalformedProtobuf
 		}
 
 		// read the field value
 		val, err := binary.ReadUvarint(r)
 		if err != nil {
-			return err
+			return ErrMalformedProtobuf
 		}
 		if typ == wireData {
 			// field is length-delimited data, skip the data
-			if val > maxFieldSize || int64(val) < 0 {
+			if val > maxFieldSize || val > uint64(r.Len()) {
 				return ErrMalformedProtobuf
 			}
 			_, err = r.Seek(int64(val), io.SeekCurrent)
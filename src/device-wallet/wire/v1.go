@@ -1,7 +1,6 @@
 package wire
 
 import (
-	"encoding/binary"
 	"errors"
 	"io"
 )
@@ -9,7 +8,6 @@ import (
 const (
 	repMarker = '?'
 	repMagic  = '#'
-	packetLen = 64
 )
 
 type Message struct {
@@ -17,90 +15,42 @@ type Message struct {
 	Data []byte
 }
 
+// WriteTo writes the reports of m to w, see Encoder
 func (m *Message) WriteTo(w io.Writer) (int64, error) {
-	var (
-		rep  [packetLen]byte
-		kind = m.Kind
-		size = uint32(len(m.Data))
-	)
-	// pack header
-	rep[0] = repMarker
-	rep[1] = repMagic
-	rep[2] = repMagic
-	binary.BigEndian.PutUint16(rep[3:], kind)
-	binary.BigEndian.PutUint32(rep[5:], size)
-
-	var (
-		written = 0 // number of written bytes
-		offset  = 9 // just after the header
-	)
-	for written < len(m.Data) {
-		n := copy(rep[offset:], m.Data[written:])
-		written += n
-		offset += n
-		if offset >= len(rep) {
-			_, err := w.Write(rep[:])
-			if err != nil {
-				return int64(written), err
-			}
-			offset = 1 // just after the marker
-		}
-	}
-	if offset != 1 {
-		for offset < len(rep) {
-			rep[offset] = 0x00
-			offset++
-		}
-		_, err := w.Write(rep[:])
-		if err != nil {
-			return int64(written), err
-		}
-	}
-
-	return int64(written), nil
+	cw := &countingWriter{w: w}
+	err := NewEncoder(cw, ReportSize).Encode(*m)
+	return cw.n, err
 }
 
 var (
 	ErrMalformedMessage = errors.New("malformed wire format")
 )
 
+// ReadFrom reads the reports of a message from r into m, see Decoder
 func (m *Message) ReadFrom(r io.Reader) (int64, error) {
-	var (
-		rep  [packetLen]byte
-		read = 0 // number of read bytes
-	)
-	n, err := r.Read(rep[:])
-	if err != nil {
-		return int64(read), err
-	}
-	read += n
-	if rep[0] != repMarker || rep[1] != repMagic || rep[2] != repMagic {
-		return int64(read), ErrMalformedMessage
-	}
+	cr := &countingReader{r: r}
+	err := NewDecoder(cr, ReportSize).Decode(m)
+	return cr.n, err
+}
 
-	// parse header
-	var (
-		kind = binary.BigEndian.Uint16(rep[3:])
-		size = binary.BigEndian.Uint32(rep[5:])
-		data = make([]byte, 0, size)
-	)
-	data = append(data, rep[9:]...) // read data after header
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
 
-	for uint32(len(data)) < size {
-		n, err := r.Read(rep[:])
-		if err != nil {
-			return int64(read), err
-		}
-		if rep[0] != repMarker {
-			return int64(read), ErrMalformedMessage
-		}
-		read += n
-		data = append(data, rep[1:]...) // read data after marker
-	}
-	data = data[:size]
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
 
-	m.Kind = kind
-	m.Data = data
+type countingReader struct {
+	r io.Reader
+	n int64
+}
 
-	return int64(read), nil
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
 }